@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// BenchmarkGol benchmarks 100 turns of the 512x512 image using 1-16 worker threads.
+func BenchmarkGol(b *testing.B) {
+	// Disable all program output apart from benchmark results
+	os.Stdout = nil
+	for threads := 1; threads <= 16; threads++ {
+		p := gol.Params{
+			Turns:       100,
+			Threads:     threads,
+			ImageWidth:  512,
+			ImageHeight: 512,
+		}
+		name := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				events := make(chan gol.Event)
+				go gol.Run(p, events, nil)
+				for range events {
+				}
+			}
+		})
+	}
+}
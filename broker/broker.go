@@ -0,0 +1,279 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Broker holds the world for a controller and splits every turn across the remote workers.
+// When a controller detaches, or its connection drops, the broker carries on by itself until a new controller attaches.
+type Broker struct {
+	mu       sync.Mutex
+	workers  []*rpc.Client
+	world    stubs.World
+	turn     int
+	turns    int
+	rule     string
+	topology int
+	started  bool
+	quit     chan bool
+	// stop closes quit once, however many times Shutdown is called
+	stop sync.Once
+
+	// detached is true while the broker runs turns without a controller.
+	// Every detach starts a new generation so that a stale run stops by itself.
+	detached   bool
+	generation int
+	// controller is the id of the connection of the controller running the world, 0 if there is none
+	controller  int
+	connections int
+}
+
+// connection serves the broker to a single client, so that the broker knows which client is its controller.
+// Every method of the broker is served through it, under the name Broker.
+type connection struct {
+	*Broker
+	id int
+}
+
+// Start replaces the world held by the broker and makes the client its controller.
+func (c *connection) Start(req stubs.StartRequest, res *stubs.Empty) error {
+	return c.start(req, c.id)
+}
+
+// Attach makes the client the controller of a detached world.
+func (c *connection) Attach(req stubs.AttachRequest, res *stubs.WorldResponse) error {
+	return c.attach(req, res, c.id)
+}
+
+func (b *Broker) start(req stubs.StartRequest, controller int) error {
+	if _, err := gol.ParseRule(req.Rule); err != nil {
+		return err
+	}
+	if req.World.Width < 1 || req.World.Height < 1 || len(req.World.Rows) != req.World.Height {
+		return fmt.Errorf("cannot start a %dx%d world with %d rows", req.World.Width, req.World.Height, len(req.World.Rows))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.detached = false
+	b.controller = controller
+	b.world = req.World
+	b.turn = req.Turn
+	b.turns = req.Turns
+	b.rule = req.Rule
+	b.topology = req.Topology
+	b.started = true
+	return nil
+}
+
+// Step calculates a single turn for the controller.
+func (b *Broker) Step(req stubs.Empty, res *stubs.StepResponse) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		return errors.New("no world has been started")
+	}
+	return b.step(res)
+}
+
+// step calculates a single turn, giving each worker a horizontal strip of the world.
+// The caller must hold the lock.
+func (b *Broker) step(res *stubs.StepResponse) error {
+	if len(b.workers) == 0 {
+		return errors.New("the broker has no workers, it has been shut down")
+	}
+	height := b.world.Height
+	threads := len(b.workers)
+	if threads > height {
+		threads = height
+	}
+
+	// the edge columns are only read by topologies that need them, but they are cheap to send
+	left := make([]bool, height)
+	right := make([]bool, height)
+	last := b.world.Width - 1
+	for y, row := range b.world.Rows {
+		left[y] = row[0]&1 != 0
+		right[y] = row[last/64]&(1<<uint(last%64)) != 0
+	}
+
+	// the first strips are one row taller if the height does not divide evenly
+	calls := make([]*rpc.Call, threads)
+	smallHeight := height / threads
+	startY := 0
+	for i := 0; i < threads; i++ {
+		endY := startY + smallHeight
+		if i < height%threads {
+			endY++
+		}
+		strip := stubs.StripRequest{
+			StartY:   startY,
+			EndY:     endY,
+			Width:    b.world.Width,
+			Height:   height,
+			Rows:     b.world.Rows[startY:endY],
+			Above:    b.world.Rows[(startY-1+height)%height],
+			Below:    b.world.Rows[endY%height],
+			Left:     left,
+			Right:    right,
+			Rule:     b.rule,
+			Topology: b.topology,
+		}
+		calls[i] = b.workers[i].Go(stubs.WorkerCalculate, strip, new(stubs.StripResponse), nil)
+		startY = endY
+	}
+
+	var rows [][]uint64
+	res.Alive = 0
+	for _, call := range calls {
+		<-call.Done
+		if call.Error != nil {
+			return call.Error
+		}
+		strip := call.Reply.(*stubs.StripResponse)
+		rows = append(rows, strip.Rows...)
+		res.Flipped = append(res.Flipped, strip.Flipped...)
+		res.Alive += strip.Alive
+	}
+	b.world.Rows = rows
+	b.turn++
+	res.Turn = b.turn
+	return nil
+}
+
+// World returns the current world and the number of turns completed so far.
+func (b *Broker) World(req stubs.Empty, res *stubs.WorldResponse) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		return errors.New("no world has been started")
+	}
+	res.World = b.world
+	res.Turn = b.turn
+	return nil
+}
+
+// Detach lets the broker carry on running turns by itself after the controller disconnects.
+func (b *Broker) Detach(req stubs.Empty, res *stubs.Empty) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		return errors.New("no world has been started")
+	}
+	if !b.detached {
+		b.detach()
+	}
+	return nil
+}
+
+// detach starts a new run without a controller. The caller must hold the lock.
+func (b *Broker) detach() {
+	b.detached = true
+	b.controller = 0
+	b.generation++
+	go b.run(b.generation)
+}
+
+// dropped detaches the controller if the connection that has closed was its connection,
+// such as when the controller crashed or lost its network.
+func (b *Broker) dropped(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started && !b.detached && b.controller == id {
+		fmt.Println("Controller disconnected, carrying on without it.")
+		b.detach()
+	}
+}
+
+// run calculates turns until the final turn, a new controller attaches or the broker shuts down.
+func (b *Broker) run(generation int) {
+	for {
+		b.mu.Lock()
+		if !b.detached || b.generation != generation || b.turn >= b.turns || b.workers == nil {
+			b.mu.Unlock()
+			return
+		}
+		err := b.step(new(stubs.StepResponse))
+		b.mu.Unlock()
+		if err != nil {
+			fmt.Println("Detached run stopped:", err)
+			return
+		}
+	}
+}
+
+// attach stops a detached run and hands the live world and its turn to a new controller.
+// It fails while another controller is attached, or if the world is not the size the controller expects,
+// in which case the run carries on.
+func (b *Broker) attach(req stubs.AttachRequest, res *stubs.WorldResponse, controller int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		return errors.New("no world has been started")
+	}
+	if !b.detached {
+		return errors.New("another controller is still running the world")
+	}
+	if b.world.Width != req.Width || b.world.Height != req.Height {
+		return fmt.Errorf("broker is running a %dx%d world, not %dx%d", b.world.Width, b.world.Height, req.Width, req.Height)
+	}
+	b.detached = false
+	b.controller = controller
+	res.World = b.world
+	res.Turn = b.turn
+	return nil
+}
+
+// Shutdown stops every worker and then the broker itself once the response has been sent.
+// The broker stops even if some workers cannot be reached, their errors are returned together.
+func (b *Broker) Shutdown(req stubs.Empty, res *stubs.Empty) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var errs []error
+	for _, worker := range b.workers {
+		err := worker.Call(stubs.WorkerShutdown, stubs.Empty{}, &stubs.Empty{})
+		if err != nil {
+			errs = append(errs, err)
+		}
+		_ = worker.Close()
+	}
+	b.workers = nil
+	b.stop.Do(func() { close(b.quit) })
+	return errors.Join(errs...)
+}
+
+// Serve connects to the workers and serves the broker on the listener until it is shut down.
+func Serve(listener net.Listener, workerAddresses []string) error {
+	if len(workerAddresses) == 0 {
+		return errors.New("a broker needs at least one worker")
+	}
+	b := &Broker{quit: make(chan bool)}
+	for _, address := range workerAddresses {
+		worker, err := rpc.Dial("tcp", address)
+		if err != nil {
+			return err
+		}
+		b.workers = append(b.workers, worker)
+	}
+
+	// every connection has its own server so that the broker can tell when its controller has gone
+	return util.ServeConns(listener, b.quit, func(conn net.Conn) {
+		b.mu.Lock()
+		b.connections++
+		c := &connection{Broker: b, id: b.connections}
+		b.mu.Unlock()
+
+		server := rpc.NewServer()
+		err := server.RegisterName("Broker", c)
+		util.Check(err)
+		server.ServeConn(conn)
+		b.dropped(c.id)
+	})
+}
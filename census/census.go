@@ -0,0 +1,352 @@
+// Package census counts the objects on a board, such as blocks, blinkers and gliders.
+package census
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Rule is an outer-totalistic rule, with the same fields as gol.Rule so that one converts to the other.
+type Rule struct {
+	Birth   [9]bool
+	Survive [9]bool
+}
+
+// Conway is the rule of Conway's Game of Life, B3/S23.
+var Conway = Rule{
+	Birth:   [9]bool{3: true},
+	Survive: [9]bool{2: true, 3: true},
+}
+
+// maxPeriod is how many generations an unknown object is simulated for to find out what it is.
+const maxPeriod = 64
+
+// known lists the objects that are recognised by name, drawn with 'O' for alive cells.
+// Every phase of an oscillator or spaceship is found by simulating it.
+var known = []struct {
+	name string
+	rows []string
+}{
+	{"block", []string{"OO", "OO"}},
+	{"beehive", []string{".OO.", "O..O", ".OO."}},
+	{"loaf", []string{".OO.", "O..O", ".O.O", "..O."}},
+	{"boat", []string{"OO.", "O.O", ".O."}},
+	{"ship", []string{"OO.", "O.O", ".OO"}},
+	{"tub", []string{".O.", "O.O", ".O."}},
+	{"pond", []string{".OO.", "O..O", "O..O", ".OO."}},
+	{"blinker", []string{"OOO"}},
+	{"toad", []string{".OOO", "OOO."}},
+	{"beacon", []string{"OO..", "O...", "...O", "..OO"}},
+	{"glider", []string{".O.", "..O", "OOO"}},
+	{"lwss", []string{".O..O", "O....", "O...O", "OOOO."}},
+}
+
+// cell is a position that is not wrapped around the board, so that an object on an edge stays in one piece.
+type cell struct{ x, y int }
+
+// Wrap maps a position, which may be off the board, onto the board the way its edges are joined.
+// It returns false for a position past an edge that is not joined to another.
+type Wrap func(x, y int) (int, int, bool)
+
+// Take splits the alive cells of a width x height board into objects and counts every kind of object.
+// Cells that are up to two cells apart are grouped together, as they can affect each other,
+// and a group that is not a known object is split into its touching pieces if some of them are.
+// Objects that are not known are named by what they do when simulated on their own:
+// "still life", "oscillator", "spaceship" or "unstable".
+func Take(width, height int, wrap Wrap, rule Rule, alive []util.Cell) map[string]int {
+	names := knownObjects(rule)
+	counts := make(map[string]int)
+	for _, group := range components(width, height, wrap, alive) {
+		for _, object := range split(group, names) {
+			name, ok := names[canonical(object)]
+			if !ok {
+				name = classify(object, rule)
+			}
+			counts[name]++
+		}
+	}
+	return counts
+}
+
+// Summary lists the counts from the most common object to the least, such as "block 3, blinker 1".
+func Summary(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%v %v", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// components groups the alive cells that are up to two cells apart with a flood fill.
+func components(width, height int, wrap Wrap, alive []util.Cell) [][]cell {
+	board := make([]bool, width*height)
+	for _, c := range alive {
+		board[c.Y*width+c.X] = true
+	}
+	visited := make([]bool, width*height)
+	var objects [][]cell
+	for _, start := range alive {
+		if visited[start.Y*width+start.X] {
+			continue
+		}
+		visited[start.Y*width+start.X] = true
+		object := []cell{{start.X, start.Y}}
+		for i := 0; i < len(object); i++ {
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					next := cell{object[i].x + dx, object[i].y + dy}
+					x, y, ok := wrap(next.x, next.y)
+					if !ok {
+						continue
+					}
+					if board[y*width+x] && !visited[y*width+x] {
+						visited[y*width+x] = true
+						object = append(object, next)
+					}
+				}
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// split returns a group as a single object if it is known or cannot be split.
+// Otherwise every touching piece of it that is known is an object of its own,
+// and the pieces that are left make up one more object.
+func split(group []cell, names map[string]string) [][]cell {
+	if _, ok := names[canonical(group)]; ok {
+		return [][]cell{group}
+	}
+	pieces := touching(group)
+	if len(pieces) == 1 {
+		return pieces
+	}
+	var objects [][]cell
+	var rest []cell
+	for _, piece := range pieces {
+		if _, ok := names[canonical(piece)]; ok {
+			objects = append(objects, piece)
+		} else {
+			rest = append(rest, piece...)
+		}
+	}
+	if len(rest) > 0 {
+		objects = append(objects, rest)
+	}
+	return objects
+}
+
+// touching splits cells into pieces where every cell is next to, or diagonal to, another cell of its piece.
+func touching(cells []cell) [][]cell {
+	unvisited := make(map[cell]bool, len(cells))
+	for _, c := range cells {
+		unvisited[c] = true
+	}
+	var pieces [][]cell
+	for _, start := range cells {
+		if !unvisited[start] {
+			continue
+		}
+		delete(unvisited, start)
+		piece := []cell{start}
+		for i := 0; i < len(piece); i++ {
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					next := cell{piece[i].x + dx, piece[i].y + dy}
+					if unvisited[next] {
+						delete(unvisited, next)
+						piece = append(piece, next)
+					}
+				}
+			}
+		}
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
+
+// normalise moves the cells so that the top left of their bounding box is at (0, 0) and sorts them.
+func normalise(cells []cell) []cell {
+	if len(cells) == 0 {
+		return nil
+	}
+	minX, minY := cells[0].x, cells[0].y
+	for _, c := range cells {
+		if c.x < minX {
+			minX = c.x
+		}
+		if c.y < minY {
+			minY = c.y
+		}
+	}
+	moved := make([]cell, len(cells))
+	for i, c := range cells {
+		moved[i] = cell{c.x - minX, c.y - minY}
+	}
+	sort.Slice(moved, func(i, j int) bool {
+		if moved[i].y != moved[j].y {
+			return moved[i].y < moved[j].y
+		}
+		return moved[i].x < moved[j].x
+	})
+	return moved
+}
+
+func key(cells []cell) string {
+	var b strings.Builder
+	for _, c := range cells {
+		fmt.Fprintf(&b, "%d,%d;", c.x, c.y)
+	}
+	return b.String()
+}
+
+// canonical returns the same key for an object in any of its 8 rotations and reflections,
+// which is the smallest key of them all.
+func canonical(cells []cell) string {
+	best := ""
+	transformed := make([]cell, len(cells))
+	for t := 0; t < 8; t++ {
+		for i, c := range cells {
+			x, y := c.x, c.y
+			if t&1 != 0 {
+				x = -x
+			}
+			if t&2 != 0 {
+				y = -y
+			}
+			if t&4 != 0 {
+				x, y = y, x
+			}
+			transformed[i] = cell{x, y}
+		}
+		if k := key(normalise(transformed)); best == "" || k < best {
+			best = k
+		}
+	}
+	return best
+}
+
+// step runs a generation of an object on its own on an infinite board.
+func step(cells []cell, rule Rule) []cell {
+	alive := make(map[cell]bool, len(cells))
+	neighbours := make(map[cell]int)
+	for _, c := range cells {
+		alive[c] = true
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					neighbours[cell{c.x + dx, c.y + dy}]++
+				}
+			}
+		}
+	}
+	var next []cell
+	for c, n := range neighbours {
+		if (alive[c] && rule.Survive[n]) || (!alive[c] && rule.Birth[n]) {
+			next = append(next, c)
+		}
+	}
+	return next
+}
+
+// period simulates an object until it has the same shape as it started with, up to maxPeriod generations.
+// It returns the period along with how far the object has moved, or 0 if it never repeats.
+func period(cells []cell, rule Rule) (int, cell) {
+	start := normalise(cells)
+	startKey := key(start)
+	current := cells
+	for generation := 1; generation <= maxPeriod; generation++ {
+		current = step(current, rule)
+		if len(current) == 0 {
+			return 0, cell{}
+		}
+		if moved := normalise(current); key(moved) == startKey {
+			return generation, cell{minX(current) - minX(cells), minY(current) - minY(cells)}
+		}
+	}
+	return 0, cell{}
+}
+
+func minX(cells []cell) int {
+	m := cells[0].x
+	for _, c := range cells {
+		if c.x < m {
+			m = c.x
+		}
+	}
+	return m
+}
+
+func minY(cells []cell) int {
+	m := cells[0].y
+	for _, c := range cells {
+		if c.y < m {
+			m = c.y
+		}
+	}
+	return m
+}
+
+// classify names an object that is not known by simulating it on its own.
+func classify(cells []cell, rule Rule) string {
+	p, moved := period(cells, rule)
+	switch {
+	case p == 0:
+		return "unstable"
+	case moved != cell{}:
+		return "spaceship"
+	case p == 1:
+		return "still life"
+	}
+	return "oscillator"
+}
+
+// namesByRule keeps the names of the known objects for every rule a census has been taken with.
+var namesByRule = struct {
+	sync.Mutex
+	names map[Rule]map[string]string
+}{names: make(map[Rule]map[string]string)}
+
+// knownObjects maps the canonical key of every phase of every known object to its name.
+// An object only counts as known if it repeats under the rule, so that other rules are not given wrong names.
+// The names are worked out once for each rule.
+func knownObjects(rule Rule) map[string]string {
+	namesByRule.Lock()
+	defer namesByRule.Unlock()
+	if names, ok := namesByRule.names[rule]; ok {
+		return names
+	}
+	names := make(map[string]string)
+	for _, object := range known {
+		var cells []cell
+		for y, row := range object.rows {
+			for x, c := range row {
+				if c == 'O' {
+					cells = append(cells, cell{x, y})
+				}
+			}
+		}
+		p, _ := period(cells, rule)
+		for phase := 0; phase < p; phase++ {
+			names[canonical(cells)] = object.name
+			cells = step(cells, rule)
+		}
+	}
+	namesByRule.names[rule] = names
+	return names
+}
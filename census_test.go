@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// place adds the 'O' cells of rows to alive with the top left at x, y, wrapping around a size x size board.
+func place(alive []util.Cell, size, x, y int, rows ...string) []util.Cell {
+	for dy, row := range rows {
+		for dx, c := range row {
+			if c == 'O' {
+				alive = append(alive, util.Cell{X: (x + dx) % size, Y: (y + dy) % size})
+			}
+		}
+	}
+	return alive
+}
+
+// TestCensus tests that objects are named whatever way round they are, even across the edge of the board.
+func TestCensus(t *testing.T) {
+	var alive []util.Cell
+	alive = place(alive, 40, 1, 1, "OO", "OO")
+	// a beehive on its side
+	alive = place(alive, 40, 10, 1, ".O.", "O.O", "O.O", ".O.")
+	alive = place(alive, 40, 20, 1, "O", "O", "O")
+	// a glider in another phase and reflected
+	alive = place(alive, 40, 1, 10, "O.O", "OO.", ".O.")
+	alive = place(alive, 40, 10, 10, ".O..O", "O....", "O...O", "OOOO.")
+	// a block and a glider split across the edges
+	alive = place(alive, 40, 39, 20, "OO", "OO")
+	alive = place(alive, 40, 30, 39, ".O.", "..O", "OOO")
+	// blocks and blinkers next to other objects are still counted on their own
+	alive = place(alive, 40, 25, 25, "OO.OO", "OO.OO")
+	alive = place(alive, 40, 25, 30, "OO.OOO", "OO....")
+	// an eater is a still life that is not known by name
+	alive = place(alive, 40, 30, 10, "OO..", "O.O.", "..O.", "..OO")
+
+	counts := census.Take(40, 40, gol.Torus.Wrap(40, 40), census.Conway, alive)
+	expected := map[string]int{"block": 5, "beehive": 1, "blinker": 2, "glider": 2, "lwss": 1, "still life": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("Expected %v, got %v", census.Summary(expected), census.Summary(counts))
+	}
+}
+
+// TestCensusEvent tests that a run with Census set sends the objects on the final board.
+func TestCensusEvent(t *testing.T) {
+	p := gol.Params{Turns: 2000, Threads: 4, ImageWidth: 64, ImageHeight: 64, Census: true}
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var counts map[string]int
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.CensusComplete:
+			if e.CompletedTurns != p.Turns {
+				t.Fatalf("Expected the census at turn %v, got %v", p.Turns, e.CompletedTurns)
+			}
+			counts = e.Counts
+		}
+	}
+	expected := map[string]int{"block": 10, "beehive": 3, "blinker": 13, "tub": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("Expected %v, got %v", census.Summary(expected), census.Summary(counts))
+	}
+}
+
+// TestCensusTopology tests that objects across an edge stay in one piece on the topologies that join it,
+// including the Klein bottle, where crossing the top edge mirrors the column.
+func TestCensusTopology(t *testing.T) {
+	tests := []struct {
+		topology gol.Topology
+		x, y     int
+		rows     []string
+		name     string
+	}{
+		{gol.Cylinder, 38, 5, []string{".O.", "..O", "OOO"}, "glider"},
+		{gol.KleinBottle, 5, -1, []string{"OO", "OO"}, "block"},
+		{gol.KleinBottle, 38, -2, []string{".O.", "..O", "OOO"}, "glider"},
+		{gol.Plane, 38, 38, []string{"OO", "OO"}, "block"},
+	}
+	for _, test := range tests {
+		t.Run(test.topology.String()+"-"+test.name, func(t *testing.T) {
+			wrap := test.topology.Wrap(40, 40)
+			var alive []util.Cell
+			for dy, row := range test.rows {
+				for dx, c := range row {
+					if c == 'O' {
+						x, y, _ := wrap(test.x+dx, test.y+dy)
+						alive = append(alive, util.Cell{X: x, Y: y})
+					}
+				}
+			}
+			counts := census.Take(40, 40, wrap, census.Conway, alive)
+			if expected := map[string]int{test.name: 1}; !reflect.DeepEqual(counts, expected) {
+				t.Fatalf("Expected %v, got %v", census.Summary(expected), census.Summary(counts))
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestCheckpoint tests that a 64x64 image resumed from the checkpoint written at turn 50
+// reports turns from 51 onwards and ends on the same board as a full run of 100 turns,
+// both for Conway's rule and for a rule that is only stored in the checkpoint.
+func TestCheckpoint(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+	}{
+		{"", "check/images/64x64x100.pgm"},
+		{"B36/S23", "check/rules/B36S23/64x64x100.pgm"},
+	}
+	for _, test := range tests {
+		p := gol.Params{
+			Turns:              100,
+			Threads:            4,
+			ImageWidth:         64,
+			ImageHeight:        64,
+			Rule:               test.rule,
+			CheckpointInterval: 50,
+		}
+		t.Run(fmt.Sprintf("%v-%dx%dx%d", test.rule, p.ImageWidth, p.ImageHeight, p.Turns), func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			for range events {
+			}
+
+			resumed := gol.Params{Turns: 100, Threads: 4, Resume: "out/64x64x50.checkpoint"}
+			events = make(chan gol.Event)
+			go gol.Run(resumed, events, nil)
+			var cells []util.Cell
+			turn := 50
+			for event := range events {
+				switch e := event.(type) {
+				case gol.CellFlipped:
+					if e.CompletedTurns < 50 {
+						t.Fatalf("CellFlipped event for turn %v after resuming from turn 50", e.CompletedTurns)
+					}
+				case gol.TurnComplete:
+					turn++
+					if e.CompletedTurns != turn {
+						t.Fatalf("Incorrect turn number for TurnComplete. Was %d, should be %d.", e.CompletedTurns, turn)
+					}
+				case gol.FinalTurnComplete:
+					cells = e.Alive
+				}
+			}
+			assertEqualBoard(t, cells, readAliveCells(test.expected, 64, 64), p)
+		})
+	}
+}
+
+// TestCheckpointChecksum tests that a corrupted checkpoint is rejected.
+func TestCheckpointChecksum(t *testing.T) {
+	p := gol.Params{Turns: 10, Threads: 1, ImageWidth: 16, ImageHeight: 16, CheckpointInterval: 10}
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	for range events {
+	}
+
+	path := "out/16x16x10.checkpoint"
+	stored, turn, err := gol.ReadCheckpointParams(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn != 10 || stored.ImageWidth != 16 || stored.ImageHeight != 16 || stored.Rule != gol.ConwayRule {
+		t.Fatalf("Checkpoint stored turn %v and params %+v", turn, stored)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	util.Check(err)
+	data[len(data)-1] ^= 0xFF
+	err = ioutil.WriteFile("out/corrupted.checkpoint", data, 0644)
+	util.Check(err)
+	if _, _, err := gol.ReadCheckpointParams("out/corrupted.checkpoint"); err == nil {
+		t.Fatal("A corrupted checkpoint was not rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/broker"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// main starts a broker with 'go run ./cmd/broker -workers 127.0.0.1:8040,127.0.0.1:8041'
+func main() {
+	port := flag.String(
+		"port",
+		"8030",
+		"Specify the port to listen on. Defaults to 8030.")
+
+	workers := flag.String(
+		"workers",
+		"127.0.0.1:8040",
+		"Specify a comma separated list of worker addresses. Defaults to 127.0.0.1:8040.")
+
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", ":"+*port)
+	util.Check(err)
+	fmt.Println("Broker listening on", listener.Addr())
+
+	err = broker.Serve(listener, strings.Split(*workers, ","))
+	util.Check(err)
+	fmt.Println("Broker shut down.")
+}
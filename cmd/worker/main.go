@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/worker"
+)
+
+// main starts a worker with 'go run ./cmd/worker -port 8040'
+func main() {
+	port := flag.String(
+		"port",
+		"8040",
+		"Specify the port to listen on. Defaults to 8040.")
+
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", ":"+*port)
+	util.Check(err)
+	fmt.Println("Worker listening on", listener.Addr())
+
+	err = worker.Serve(listener)
+	util.Check(err)
+	fmt.Println("Worker shut down.")
+}
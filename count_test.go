@@ -96,3 +96,45 @@ func readAliveCounts(width, height int) map[int]int {
 	}
 	return alive
 }
+
+// TestAliveTurns tests that AliveCellsCount is sent after exactly every AliveCellsTurns turns,
+// and that a negative AliveCellsPeriod sends none.
+func TestAliveTurns(t *testing.T) {
+	alive := readAliveCounts(64, 64)
+	t.Run("every-100-turns", func(t *testing.T) {
+		p := gol.Params{Turns: 1000, Threads: 4, ImageWidth: 64, ImageHeight: 64, AliveCellsTurns: 100}
+		events := make(chan gol.Event)
+		go gol.Run(p, events, nil)
+		next := 100
+		for event := range events {
+			switch e := event.(type) {
+			case gol.ErrorOccurred:
+				t.Fatal(e.Err)
+			case gol.AliveCellsCount:
+				if e.CompletedTurns != next {
+					t.Fatalf("Expected a count at turn %v, got one at turn %v", next, e.CompletedTurns)
+				}
+				if e.CellsCount != alive[next] {
+					t.Fatalf("At turn %v expected %v alive cells, got %v instead", next, alive[next], e.CellsCount)
+				}
+				next += 100
+			}
+		}
+		if next != 1100 {
+			t.Fatalf("Expected counts up to turn 1000, the last was at turn %v", next-100)
+		}
+	})
+	t.Run("none", func(t *testing.T) {
+		p := gol.Params{Turns: 60000, Threads: 4, ImageWidth: 64, ImageHeight: 64, TurnsPerSecond: 20000, AliveCellsPeriod: -1}
+		events := make(chan gol.Event)
+		go gol.Run(p, events, nil)
+		for event := range events {
+			switch e := event.(type) {
+			case gol.ErrorOccurred:
+				t.Fatal(e.Err)
+			case gol.AliveCellsCount:
+				t.Fatalf("Expected no counts, got one at turn %v", e.CompletedTurns)
+			}
+		}
+	})
+}
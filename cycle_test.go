@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestCycle tests that cycles are found on the turn the world first repeats, and that fast forwarding
+// skips to the same final world as a full run would reach.
+func TestCycle(t *testing.T) {
+	tests := []struct {
+		size         int
+		turns        int
+		detectCycles int
+		fastForward  bool
+		// the turn and period of the expected CycleDetected event, a period of 0 expects none
+		cycleTurn, period int
+		expected          string
+	}{
+		// a glider on a 16x16 torus is back where it started after 64 turns
+		{16, 1000000000, 64, true, 64, 64, "check/images/16x16x0.pgm"},
+		{16, 100, 63, false, 0, 0, "check/images/16x16x100.pgm"},
+		// 64x64 settles into still lifes and blinkers on turn 1575
+		{64, 1000000001, 16, true, 1577, 2, "check/cycle/64x64x1000000001.pgm"},
+		{64, 2000, 16, false, 1577, 2, ""},
+	}
+	for _, test := range tests {
+		p := gol.Params{
+			Turns:        test.turns,
+			Threads:      4,
+			ImageWidth:   test.size,
+			ImageHeight:  test.size,
+			DetectCycles: test.detectCycles,
+			FastForward:  test.fastForward,
+		}
+		t.Run(fmt.Sprintf("%dx%dx%d-%d-%v", test.size, test.size, test.turns, test.detectCycles, test.fastForward), func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			var cycles []gol.CycleDetected
+			turns := 0
+			var final gol.FinalTurnComplete
+			for event := range events {
+				switch e := event.(type) {
+				case gol.ErrorOccurred:
+					t.Fatal(e.Err)
+				case gol.CycleDetected:
+					cycles = append(cycles, e)
+				case gol.TurnComplete:
+					turns++
+				case gol.FinalTurnComplete:
+					final = e
+				}
+			}
+
+			if test.period == 0 && len(cycles) > 0 {
+				t.Fatalf("Expected no cycle, got %v", cycles)
+			}
+			if test.period > 0 && (len(cycles) != 1 || cycles[0] != gol.CycleDetected{CompletedTurns: test.cycleTurn, Period: test.period}) {
+				t.Fatalf("Expected a cycle of period %v at turn %v, got %+v", test.period, test.cycleTurn, cycles)
+			}
+			if final.CompletedTurns != test.turns {
+				t.Fatalf("Expected the final turn to be %v, got %v", test.turns, final.CompletedTurns)
+			}
+			// a fast forward reports the turns up to the cycle lining up, and then the last turn
+			if !test.fastForward && turns != test.turns {
+				t.Fatalf("Expected %v TurnComplete events, got %v", test.turns, turns)
+			}
+			if test.fastForward && turns > test.cycleTurn+test.period {
+				t.Fatalf("Expected at most %v TurnComplete events, got %v", test.cycleTurn+test.period, turns)
+			}
+			if test.expected != "" {
+				assertEqualBoard(t, final.Alive, readAliveCells(test.expected, test.size, test.size), p)
+			}
+		})
+	}
+}
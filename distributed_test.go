@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/broker"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/worker"
+)
+
+// startCluster starts a broker with the given number of workers on localhost.
+// It returns the address of the broker and a channel that receives when the broker and all workers have shut down.
+func startCluster(t *testing.T, workers int) (string, chan bool) {
+	var addresses []string
+	workersDone := make(chan bool, workers)
+	for i := 0; i < workers; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		util.Check(err)
+		addresses = append(addresses, listener.Addr().String())
+		go func() {
+			if err := worker.Serve(listener); err != nil {
+				t.Error(err)
+			}
+			workersDone <- true
+		}()
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	done := make(chan bool, 1)
+	go func() {
+		if err := broker.Serve(listener, addresses); err != nil {
+			t.Error(err)
+		}
+		for i := 0; i < workers; i++ {
+			<-workersDone
+		}
+		done <- true
+	}()
+	return listener.Addr().String(), done
+}
+
+// TestDistributed tests 16x16, 64x64 and 512x512 images on 0, 1 and 100 turns using 1-4 remote workers.
+// Every run connects a new controller to the same broker.
+func TestDistributed(t *testing.T) {
+	tests := []gol.Params{
+		{ImageWidth: 16, ImageHeight: 16},
+		{ImageWidth: 64, ImageHeight: 64},
+		{ImageWidth: 512, ImageHeight: 512},
+	}
+	for workers := 1; workers <= 4; workers++ {
+		address, _ := startCluster(t, workers)
+		for _, p := range tests {
+			p.Broker = address
+			for _, turns := range []int{0, 1, 100} {
+				p.Turns = turns
+				expectedAlive := readAliveCells(
+					"check/images/"+fmt.Sprintf("%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, turns),
+					p.ImageWidth,
+					p.ImageHeight,
+				)
+				testName := fmt.Sprintf("%dx%dx%d-%dworkers", p.ImageWidth, p.ImageHeight, p.Turns, workers)
+				t.Run(testName, func(t *testing.T) {
+					events := make(chan gol.Event)
+					go gol.Run(p, events, nil)
+					var cells []util.Cell
+					for event := range events {
+						switch e := event.(type) {
+						case gol.FinalTurnComplete:
+							cells = e.Alive
+						}
+					}
+					assertEqualBoard(t, cells, expectedAlive, p)
+				})
+			}
+		}
+	}
+}
+
+// TestDistributedKeyPresses tests that q only disconnects the controller and k shuts down the whole cluster.
+func TestDistributedKeyPresses(t *testing.T) {
+	address, done := startCluster(t, 2)
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100000, Broker: address}
+
+	for _, key := range []rune{'q', 'k'} {
+		events := make(chan gol.Event)
+		keyPresses := make(chan rune, 1)
+		go gol.Run(p, events, keyPresses)
+		state := gol.Executing
+		for event := range events {
+			switch e := event.(type) {
+			case gol.TurnComplete:
+				if e.CompletedTurns == 10 {
+					keyPresses <- key
+				}
+			case gol.StateChange:
+				state = e.NewState
+			}
+		}
+		if state != gol.Quitting {
+			t.Fatalf("Expected the controller to quit after %c, the last state was %v", key, state)
+		}
+
+		select {
+		case <-done:
+			if key == 'q' {
+				t.Fatal("The cluster shut down after q, only the controller should have quit")
+			}
+		case <-time.After(time.Second):
+			if key == 'k' {
+				t.Fatal("The cluster is still running 1s after k")
+			}
+		}
+	}
+}
+
+// TestDistributedAttach tests that the broker carries on after q and that a new controller
+// picks up the live world at its current turn and runs it to the final turn.
+func TestDistributedAttach(t *testing.T) {
+	address, _ := startCluster(t, 2)
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 5000, Broker: address}
+	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 1)
+	go gol.Run(p, events, keyPresses)
+	quitTurn := 0
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 10 {
+				keyPresses <- 'q'
+			}
+			quitTurn = e.CompletedTurns
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	p.Attach = true
+	events = make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	board := make(map[util.Cell]bool)
+	attachTurn := -1
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			if attachTurn == -1 {
+				attachTurn = e.CompletedTurns
+			}
+			board[e.Cell] = !board[e.Cell]
+		case gol.TurnComplete:
+			count := 0
+			for _, isAlive := range board {
+				if isAlive {
+					count++
+				}
+			}
+			if count != alive[e.CompletedTurns] {
+				t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, alive[e.CompletedTurns], count)
+			}
+		case gol.FinalTurnComplete:
+			if len(e.Alive) != alive[p.Turns] {
+				t.Fatalf("At turn %v expected %v alive cells, got %v instead", p.Turns, alive[p.Turns], len(e.Alive))
+			}
+		}
+	}
+
+	if attachTurn <= quitTurn {
+		t.Fatalf("The broker did not carry on after q: quit at turn %v, attached at turn %v", quitTurn, attachTurn)
+	}
+}
+
+// TestDistributedUnreachable tests that a broker that cannot be reached is reported as an error
+// rather than crashing the controller.
+func TestDistributedUnreachable(t *testing.T) {
+	// nothing listens on the port once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	address := listener.Addr().String()
+	util.Check(listener.Close())
+
+	for _, attach := range []bool{false, true} {
+		t.Run(fmt.Sprintf("attach=%v", attach), func(t *testing.T) {
+			p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 10, Broker: address, Attach: attach}
+			runUntilError(t, p)
+		})
+	}
+}
+
+// TestDistributedShutdownTwice tests that every Shutdown call gets a reply,
+// even a second one, and that the cluster stops once the caller hangs up.
+func TestDistributedShutdownTwice(t *testing.T) {
+	address, done := startCluster(t, 2)
+	client, err := rpc.Dial("tcp", address)
+	util.Check(err)
+	for i := 0; i < 2; i++ {
+		if err := client.Call(stubs.BrokerShutdown, stubs.Empty{}, &stubs.Empty{}); err != nil {
+			t.Fatalf("Shutdown call %v failed: %v", i+1, err)
+		}
+	}
+	util.Check(client.Close())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The cluster is still running 1s after shutting down")
+	}
+}
+
+// TestDistributedAttachBusy tests that a controller cannot attach while another one is still running the world.
+func TestDistributedAttachBusy(t *testing.T) {
+	address, _ := startCluster(t, 2)
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100000, Broker: address}
+
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 1)
+	go gol.Run(p, events, keyPresses)
+	for event := range events {
+		if e, ok := event.(gol.TurnComplete); ok && e.CompletedTurns == 10 {
+			attach := p
+			attach.Attach = true
+			runUntilError(t, attach)
+			keyPresses <- 'k'
+		}
+	}
+}
+
+// TestDistributedAttachWrongSize tests that a controller with the wrong size of board cannot attach,
+// and that it does not stop another controller from attaching afterwards.
+func TestDistributedAttachWrongSize(t *testing.T) {
+	address, _ := startCluster(t, 2)
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100000, Broker: address}
+
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 1)
+	go gol.Run(p, events, keyPresses)
+	for event := range events {
+		if e, ok := event.(gol.TurnComplete); ok && e.CompletedTurns == 10 {
+			keyPresses <- 'q'
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	wrong := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100000, Broker: address, Attach: true}
+	runUntilError(t, wrong)
+
+	p.Attach = true
+	events = make(chan gol.Event)
+	keyPresses = make(chan rune, 1)
+	go gol.Run(p, events, keyPresses)
+	attached := false
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.TurnComplete:
+			if !attached {
+				attached = true
+				keyPresses <- 'k'
+			}
+		}
+	}
+	if !attached {
+		t.Fatal("The controller with the right size could not attach")
+	}
+}
+
+// TestDistributedAttachAfterDrop tests that a controller whose connection drops counts as detached,
+// so that another controller can attach to the world it was running.
+func TestDistributedAttachAfterDrop(t *testing.T) {
+	address, _ := startCluster(t, 2)
+	client, err := rpc.Dial("tcp", address)
+	util.Check(err)
+	rows := make([][]uint64, 16)
+	for i := range rows {
+		rows[i] = make([]uint64, 1)
+	}
+	req := stubs.StartRequest{World: stubs.World{Width: 16, Height: 16, Rows: rows}, Turns: 100, Rule: gol.ConwayRule}
+	err = client.Call(stubs.BrokerStart, req, &stubs.Empty{})
+	util.Check(err)
+	_ = client.Close()
+	time.Sleep(200 * time.Millisecond)
+
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100, Broker: address, Attach: true}
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 1)
+	go gol.Run(p, events, keyPresses)
+	final := false
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.FinalTurnComplete:
+			final = true
+			if e.CompletedTurns != 100 {
+				t.Errorf("Expected the attached run to finish on turn 100, got %v", e.CompletedTurns)
+			}
+		}
+	}
+	if !final {
+		t.Fatal("The controller could not attach after the first one dropped")
+	}
+}
+
+// TestDistributedShutdownLostWorker tests that the broker still shuts down, and reports the error,
+// when one of its workers has gone away.
+func TestDistributedShutdownLostWorker(t *testing.T) {
+	lost, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	go func() {
+		for {
+			conn, err := lost.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	defer lost.Close()
+	alive, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	go worker.Serve(alive)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	done := make(chan bool, 1)
+	go func() {
+		if err := broker.Serve(listener, []string{alive.Addr().String(), lost.Addr().String()}); err != nil {
+			t.Error(err)
+		}
+		done <- true
+	}()
+
+	client, err := rpc.Dial("tcp", listener.Addr().String())
+	util.Check(err)
+	if err := client.Call(stubs.BrokerShutdown, stubs.Empty{}, &stubs.Empty{}); err == nil {
+		t.Error("Expected an error from the worker that has gone away")
+	}
+	_ = client.Close()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("The broker is still running after shutting down")
+	}
+}
+
+// TestDistributedBadWorld tests that the broker refuses an empty world,
+// and that it reports an error rather than crashing when asked for a turn after shutting down.
+func TestDistributedBadWorld(t *testing.T) {
+	address, _ := startCluster(t, 2)
+	client, err := rpc.Dial("tcp", address)
+	util.Check(err)
+	defer client.Close()
+
+	empty := stubs.StartRequest{World: stubs.World{Width: 0, Height: 0}, Turns: 10, Rule: gol.ConwayRule}
+	if err := client.Call(stubs.BrokerStart, empty, &stubs.Empty{}); err == nil {
+		t.Error("Expected the broker to refuse a 0x0 world")
+	}
+
+	rows := [][]uint64{{0}, {0}, {0}, {0}}
+	req := stubs.StartRequest{World: stubs.World{Width: 4, Height: 4, Rows: rows}, Turns: 10, Rule: gol.ConwayRule}
+	err = client.Call(stubs.BrokerStart, req, &stubs.Empty{})
+	util.Check(err)
+	err = client.Call(stubs.BrokerShutdown, stubs.Empty{}, &stubs.Empty{})
+	util.Check(err)
+	if err := client.Call(stubs.BrokerStep, stubs.Empty{}, new(stubs.StepResponse)); err == nil {
+		t.Error("Expected an error from a turn after the broker has shut down")
+	}
+}
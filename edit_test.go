@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEdits tests that cells edited while paused are flipped, reported as CellFlipped events
+// and carried on from when the run is resumed.
+func TestEdits(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	// a vertical blinker is the same after an even number of turns
+	blinker := map[util.Cell]bool{{X: 7, Y: 6}: true, {X: 7, Y: 7}: true, {X: 7, Y: 8}: true}
+
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 2)
+	edits := make(chan util.Cell, p.ImageWidth*p.ImageHeight)
+	go gol.RunWithEdits(p, events, keyPresses, edits)
+
+	board := make(map[util.Cell]bool)
+	pausedTurn := -1
+	pending := 0
+	var final []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+			if pausedTurn >= 0 && pending > 0 {
+				pending--
+				if pending == 0 {
+					keyPresses <- 'p'
+				}
+			}
+		case gol.TurnComplete:
+			if e.CompletedTurns == 10 {
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				// turn the board into the blinker by toggling every cell that differs
+				pausedTurn = e.CompletedTurns
+				for y := 0; y < p.ImageHeight; y++ {
+					for x := 0; x < p.ImageWidth; x++ {
+						cell := util.Cell{X: x, Y: y}
+						if board[cell] != blinker[cell] {
+							edits <- cell
+							pending++
+						}
+					}
+				}
+			}
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	}
+	if pausedTurn == -1 {
+		t.Fatal("The board was never paused")
+	}
+	if pending != 0 {
+		t.Fatalf("%v edits were not reported as CellFlipped events", pending)
+	}
+
+	expected := make([]util.Cell, 0, len(blinker))
+	for cell := range blinker {
+		if (p.Turns-pausedTurn)%2 == 1 {
+			// an odd number of turns leaves the blinker horizontal
+			cell = util.Cell{X: cell.Y, Y: 7}
+		}
+		expected = append(expected, cell)
+	}
+	assertEqualBoard(t, final, expected, p)
+}
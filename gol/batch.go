@@ -0,0 +1,131 @@
+package gol
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/census"
+)
+
+// defaultBatchPeriod is the longest cycle looked for in a batch if Params.DetectCycles is 0.
+const defaultBatchPeriod = 64
+
+// soupResult is how a single soup of a batch ended up.
+type soupResult struct {
+	seed int64
+	// lifespan is the turn the soup settled into a cycle on, or -1 if it had not by the last turn
+	lifespan   int
+	population int
+	counts     map[string]int
+}
+
+// runSoup runs a single soup on one thread until it is in a cycle or reaches p.Turns.
+func runSoup(p Params, rule Rule, seed int64, maxPeriod int) (soupResult, error) {
+	soup := Soup{p.Soup.Density, seed}
+	world := newBitGrid(p.ImageHeight, p.ImageWidth)
+	for i, pixel := range soup.image(p.ImageWidth, p.ImageHeight) {
+		if pixel != 0 {
+			world.set(i/p.ImageWidth, i%p.ImageWidth, true)
+		}
+	}
+	cycles := newCycleDetector(world, 0, maxPeriod)
+
+	// p.Threads is how many soups run at once, each soup only has one
+	p.Threads = 1
+	engine, err := startStepper(world, p, rule, 0)
+	if err != nil {
+		return soupResult{}, err
+	}
+	result := soupResult{seed: seed, lifespan: -1}
+	turn := 0
+	for turn < p.Turns {
+		flipped, completed, err := engine.step(p.Turns - turn)
+		if err != nil {
+			engine.stop()
+			return soupResult{}, err
+		}
+		turn += completed
+		if period := cycles.step(flipped, turn); period > 0 {
+			result.lifespan = turn - period
+			break
+		}
+	}
+	world, err = engine.world()
+	engine.stop()
+	if err != nil {
+		return soupResult{}, err
+	}
+
+	result.population = world.aliveCellCount()
+	result.counts = census.Take(world.width, world.height, p.Topology.Wrap(world.width, world.height), census.Rule(rule), world.aliveCells())
+	return result, nil
+}
+
+// RunBatch runs count soups of p.Soup.Density with the seeds from p.Soup.Seed upwards, p.Threads at a time.
+// Each soup runs until it settles into a cycle of up to p.DetectCycles turns, or until p.Turns.
+// A row of CSV is written for every soup in order of seed, with the turn it settled on,
+// which is left empty if it never did, its final population and a census of its final board.
+// Soups run locally with the RowStrips engine, a broker or another engine is an error.
+func RunBatch(p Params, count int, w io.Writer) error {
+	rule, err := ParseRule(p.Rule)
+	if err != nil {
+		return err
+	}
+	if p.Broker != "" {
+		return fmt.Errorf("a batch runs locally, not on the broker at %s", p.Broker)
+	}
+	// the cycle is looked for after every turn, so HashLife jumps would find it late
+	if p.Engine != RowStrips {
+		return fmt.Errorf("a batch only supports the %v engine, not %v", RowStrips, p.Engine)
+	}
+	maxPeriod := p.DetectCycles
+	if maxPeriod < 1 {
+		maxPeriod = defaultBatchPeriod
+	}
+	threads := p.Threads
+	if threads < 1 {
+		threads = 1
+	}
+
+	results := make([]soupResult, count)
+	errs := make([]error, count)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results[job], errs[job] = runSoup(p, rule, p.Soup.Seed+int64(job), maxPeriod)
+			}
+		}()
+	}
+	for job := 0; job < count; job++ {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"seed", "lifespan", "final_population", "census"})
+	for i, result := range results {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		lifespan := ""
+		if result.lifespan >= 0 {
+			lifespan = strconv.Itoa(result.lifespan)
+		}
+		_ = out.Write([]string{
+			strconv.FormatInt(result.seed, 10),
+			lifespan,
+			strconv.Itoa(result.population),
+			census.Summary(result.counts),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
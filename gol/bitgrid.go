@@ -0,0 +1,235 @@
+package gol
+
+import (
+	"math/bits"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// bitGrid is a bit-packed world, each row stores 64 cells per uint64.
+// Bit i of word j in a row holds the cell in column j*64+i.
+// Bits past the width of the world are always kept at 0.
+type bitGrid struct {
+	width, height int
+	rows          [][]uint64
+}
+
+// wordsFor returns the number of words needed to store the given number of cells.
+func wordsFor(cells int) int {
+	return (cells + 63) / 64
+}
+
+// newBitGrid allocates a world of dead cells.
+func newBitGrid(height, width int) *bitGrid {
+	g := &bitGrid{width: width, height: height}
+	g.rows = make([][]uint64, height)
+	for i := range g.rows {
+		g.rows[i] = make([]uint64, wordsFor(width))
+	}
+	return g
+}
+
+// clone returns a copy of the world that shares no rows with it.
+func (g *bitGrid) clone() *bitGrid {
+	c := &bitGrid{width: g.width, height: g.height, rows: make([][]uint64, g.height)}
+	for i, row := range g.rows {
+		c.rows[i] = append([]uint64(nil), row...)
+	}
+	return c
+}
+
+func (g *bitGrid) get(row, col int) bool {
+	return g.rows[row][col/64]&(1<<uint(col%64)) != 0
+}
+
+func (g *bitGrid) set(row, col int, alive bool) {
+	if alive {
+		g.rows[row][col/64] |= 1 << uint(col%64)
+	} else {
+		g.rows[row][col/64] &^= 1 << uint(col%64)
+	}
+}
+
+// aliveCellCount counts the alive cells a word at a time.
+func (g *bitGrid) aliveCellCount() int {
+	count := 0
+	for _, row := range g.rows {
+		count += countSetBits(row)
+	}
+	return count
+}
+
+// countSetBits counts the alive cells in a row.
+func countSetBits(row []uint64) int {
+	count := 0
+	for _, word := range row {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// aliveCells returns the alive cells in row-major order.
+func (g *bitGrid) aliveCells() []util.Cell {
+	var cells []util.Cell
+	for y, row := range g.rows {
+		cells = appendSetBits(cells, row, y)
+	}
+	return cells
+}
+
+// flippedCells returns the cells that differ between g and other in row-major order.
+func (g *bitGrid) flippedCells(other *bitGrid) []util.Cell {
+	var cells []util.Cell
+	diff := make([]uint64, wordsFor(g.width))
+	for y, row := range g.rows {
+		for j := range row {
+			diff[j] = row[j] ^ other.rows[y][j]
+		}
+		cells = appendSetBits(cells, diff, y)
+	}
+	return cells
+}
+
+// appendSetBits appends a cell for every set bit in a row.
+func appendSetBits(cells []util.Cell, row []uint64, y int) []util.Cell {
+	for j, word := range row {
+		for word != 0 {
+			i := bits.TrailingZeros64(word)
+			cells = append(cells, util.Cell{X: j*64 + i, Y: y})
+			word &= word - 1
+		}
+	}
+	return cells
+}
+
+// worldReader gives access to the cells needed to calculate rows of the next turn.
+// A bitGrid can read every cell, a worker only the cells around its own strip.
+type worldReader interface {
+	// row returns row y of the world, y is always within one row of the rows being calculated.
+	row(y int) []uint64
+	// get returns a cell that a neighbour outside the world was mapped onto by the topology.
+	get(row, col int) bool
+}
+
+func (g *bitGrid) row(y int) []uint64 {
+	return g.rows[y]
+}
+
+// extendedRow fills ext with row y plus one extra cell on each side, so bit k holds column k-1.
+// Cells outside the world are looked up through the topology, which is why y may be -1 or height.
+func extendedRow(world worldReader, ext []uint64, y, height, width int, topology Topology) {
+	for j := range ext {
+		ext[j] = 0
+	}
+	if y >= 0 && y < height {
+		// shift the whole row one bit towards the more significant end
+		row := world.row(y)
+		var carry uint64
+		for j, word := range row {
+			ext[j] = word<<1 | carry
+			carry = word >> 63
+		}
+		if len(ext) > len(row) {
+			ext[len(row)] |= carry
+		}
+		setExtended(world, ext, 0, y, -1, height, width, topology)
+		setExtended(world, ext, width+1, y, width, height, width, topology)
+		return
+	}
+	// rows beyond the edge are rare (two per turn) so they are built cell by cell
+	for k := 0; k < width+2; k++ {
+		setExtended(world, ext, k, y, k-1, height, width, topology)
+	}
+}
+
+// setExtended sets bit k of an extended row if the cell at (row, col) exists and is alive.
+func setExtended(world worldReader, ext []uint64, k, row, col, height, width int, topology Topology) {
+	nRow, nCol, exists := topology.neighbour(row, col, height, width)
+	if exists && world.get(nRow, nCol) {
+		ext[k/64] |= 1 << uint(k%64)
+	}
+}
+
+// wordAt returns the 64 bits of an extended row starting at bit offset.
+func wordAt(ext []uint64, offset int) uint64 {
+	j, shift := offset/64, uint(offset%64)
+	word := ext[j] >> shift
+	if shift != 0 && j+1 < len(ext) {
+		word |= ext[j+1] << (64 - shift)
+	}
+	return word
+}
+
+// neighbourCounter is a bit-sliced counter, it holds 64 neighbour counts (0-8) in four bit planes.
+type neighbourCounter [4]uint64
+
+// add increments the count of every cell whose bit is set in word.
+func (n *neighbourCounter) add(word uint64) {
+	for i := range n {
+		carry := n[i] & word
+		n[i] ^= word
+		word = carry
+	}
+}
+
+// equals returns a mask of the cells whose count is exactly count.
+func (n *neighbourCounter) equals(count int) uint64 {
+	mask := ^uint64(0)
+	for i, plane := range n {
+		if count&(1<<uint(i)) != 0 {
+			mask &= plane
+		} else {
+			mask &^= plane
+		}
+	}
+	return mask
+}
+
+// nextRows calculates rows [startY, endY) of the next turn into out using bitwise operations on whole words.
+func nextRows(world worldReader, out [][]uint64, startY, endY, height, width int, rule Rule, topology Topology) {
+	words := wordsFor(width)
+	lastMask := ^uint64(0)
+	if width%64 != 0 {
+		lastMask = 1<<uint(width%64) - 1
+	}
+
+	up := make([]uint64, wordsFor(width+2))
+	mid := make([]uint64, wordsFor(width+2))
+	down := make([]uint64, wordsFor(width+2))
+	extendedRow(world, up, startY-1, height, width, topology)
+	extendedRow(world, mid, startY, height, width, topology)
+	for y := startY; y < endY; y++ {
+		extendedRow(world, down, y+1, height, width, topology)
+		row := out[y-startY]
+		for j := range row {
+			offset := j * 64
+			var counter neighbourCounter
+			counter.add(wordAt(up, offset))
+			counter.add(wordAt(up, offset+1))
+			counter.add(wordAt(up, offset+2))
+			counter.add(wordAt(mid, offset))
+			counter.add(wordAt(mid, offset+2))
+			counter.add(wordAt(down, offset))
+			counter.add(wordAt(down, offset+1))
+			counter.add(wordAt(down, offset+2))
+
+			alive := world.row(y)[j]
+			var next uint64
+			for count := 0; count <= 8; count++ {
+				if !rule.Birth[count] && !rule.Survive[count] {
+					continue
+				}
+				equal := counter.equals(count)
+				if rule.Birth[count] {
+					next |= equal &^ alive
+				}
+				if rule.Survive[count] {
+					next |= equal & alive
+				}
+			}
+			row[j] = next
+		}
+		row[words-1] &= lastMask
+		up, mid, down = mid, down, up
+	}
+}
@@ -0,0 +1,57 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// decodeCells reads a plaintext pattern, where '.' is a dead cell and 'O' an alive one.
+// Lines starting with '!' are comments and rows may be shorter than the widest one.
+// There is no header, so if headerOnly is true only the lengths of the lines are read for the size.
+func decodeCells(r io.Reader, headerOnly bool) (pattern, error) {
+	var p pattern
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		if !headerOnly {
+			for x, b := range line {
+				switch b {
+				case '.':
+				case 'O', '*':
+					p.alive = append(p.alive, [2]int{x, p.height})
+				default:
+					return p, fmt.Errorf("%w: unexpected %q in cells data", ErrBadPattern, b)
+				}
+			}
+		}
+		if len(line) > p.width {
+			p.width = len(line)
+		}
+		p.height++
+	}
+	return p, scanner.Err()
+}
+
+// encodeCells writes every row of the world in full, so that two boards of the same size can be diffed line by line.
+func encodeCells(w io.Writer, world *bitGrid, p Params) error {
+	buffered := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(buffered, "!Name: %dx%d\n!Rule: %s\n", world.width, world.height, p.Rule)
+	line := make([]byte, world.width+1)
+	line[world.width] = '\n'
+	for y := 0; y < world.height; y++ {
+		for x := 0; x < world.width; x++ {
+			if world.get(y, x) {
+				line[x] = 'O'
+			} else {
+				line[x] = '.'
+			}
+		}
+		_, _ = buffered.Write(line)
+	}
+	return buffered.Flush()
+}
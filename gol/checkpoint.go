@@ -0,0 +1,102 @@
+package gol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// checkpointMagic starts every checkpoint file, the number is the version of the format.
+const checkpointMagic = "GOLCHECKPOINT 1\n"
+
+// checkpoint is the full state of a simulation, enough to carry on from the same turn.
+type checkpoint struct {
+	Params Params
+	Rule   string
+	Turn   int
+	World  [][]uint64
+}
+
+// newCheckpoint captures the world at the given completed turn.
+func newCheckpoint(p Params, rule Rule, turn int, world *bitGrid) checkpoint {
+	p.ImageWidth = world.width
+	p.ImageHeight = world.height
+	p.Rule = rule.String()
+	return checkpoint{Params: p, Rule: p.Rule, Turn: turn, World: world.rows}
+}
+
+func (cp checkpoint) world() *bitGrid {
+	return &bitGrid{width: cp.Params.ImageWidth, height: cp.Params.ImageHeight, rows: cp.World}
+}
+
+// encodeCheckpoint writes the magic line, a CRC32 checksum of the payload and the gob encoded payload.
+func encodeCheckpoint(w io.Writer, cp checkpoint) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(cp); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, checkpointMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, crc32.ChecksumIEEE(payload.Bytes())); err != nil {
+		return err
+	}
+	_, err := w.Write(payload.Bytes())
+	return err
+}
+
+// decodeCheckpoint reads a checkpoint written by encodeCheckpoint and verifies its checksum.
+func decodeCheckpoint(r io.Reader) (checkpoint, error) {
+	var cp checkpoint
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != checkpointMagic {
+		return cp, fmt.Errorf("%w: not a checkpoint file", ErrBadCheckpoint)
+	}
+
+	var checksum uint32
+	if err := binary.Read(reader, binary.BigEndian, &checksum); err != nil {
+		return cp, fmt.Errorf("%w: %v", ErrBadCheckpoint, err)
+	}
+	var payload bytes.Buffer
+	if _, err := payload.ReadFrom(reader); err != nil {
+		return cp, err
+	}
+	if crc32.ChecksumIEEE(payload.Bytes()) != checksum {
+		return cp, fmt.Errorf("%w: checksum mismatch", ErrBadCheckpoint)
+	}
+
+	if err := gob.NewDecoder(&payload).Decode(&cp); err != nil {
+		return cp, fmt.Errorf("%w: %v", ErrBadCheckpoint, err)
+	}
+	if len(cp.World) != cp.Params.ImageHeight {
+		return cp, fmt.Errorf("%w: %d rows, expected %d", ErrBadCheckpoint, len(cp.World), cp.Params.ImageHeight)
+	}
+	for _, row := range cp.World {
+		if len(row) != wordsFor(cp.Params.ImageWidth) {
+			return cp, fmt.Errorf("%w: row has %d words, expected %d", ErrBadCheckpoint, len(row), wordsFor(cp.Params.ImageWidth))
+		}
+	}
+	return cp, nil
+}
+
+// ReadCheckpointParams returns the params stored in a checkpoint and the turn it was taken at,
+// so that the image size and rule are known before the checkpoint is resumed.
+func ReadCheckpointParams(path string) (Params, int, error) {
+	cp, err := readCheckpointFile(path)
+	return cp.Params, cp.Turn, err
+}
+
+func readCheckpointFile(path string) (checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return checkpoint{}, err
+	}
+	defer file.Close()
+	return decodeCheckpoint(file)
+}
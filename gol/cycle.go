@@ -0,0 +1,98 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// cellKey returns a random looking 64 bit key for the cell at index i, using the splitmix64 finaliser.
+// The hash of a world is the xor of the keys of its alive cells, so it can be updated from the flipped cells alone.
+func cellKey(i int) uint64 {
+	z := uint64(i) + 0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// cycleDetector hashes every generation and looks for a hash seen in the last maxPeriod turns.
+// A matching hash is only a cycle once the cells flipped since then show that the world really is the same.
+type cycleDetector struct {
+	width     int
+	height    int
+	maxPeriod int
+	hash      uint64
+	// seen maps the hashes in recent to the turn they were seen on
+	seen   map[uint64]int
+	recent []seenHash
+	next   int
+}
+
+type seenHash struct {
+	hash uint64
+	turn int
+	// flipped are the cells flipped to reach the turn
+	flipped []util.Cell
+}
+
+func newCycleDetector(world *bitGrid, turn, maxPeriod int) *cycleDetector {
+	d := &cycleDetector{width: world.width, height: world.height, maxPeriod: maxPeriod}
+	d.reset(world, turn)
+	return d
+}
+
+// reset forgets every hash and starts again from the world, such as after it has been edited.
+func (d *cycleDetector) reset(world *bitGrid, turn int) {
+	d.hash = 0
+	for _, cell := range world.aliveCells() {
+		d.hash ^= cellKey(cell.Y*d.width + cell.X)
+	}
+	d.seen = make(map[uint64]int)
+	d.recent = make([]seenHash, 0, d.maxPeriod)
+	d.next = 0
+	d.add(turn, nil)
+}
+
+func (d *cycleDetector) add(turn int, flipped []util.Cell) {
+	entry := seenHash{d.hash, turn, flipped}
+	if len(d.recent) < d.maxPeriod {
+		d.recent = append(d.recent, entry)
+	} else {
+		// forget the oldest hash, unless the same world has been seen again since
+		oldest := d.recent[d.next]
+		if d.seen[oldest.hash] == oldest.turn {
+			delete(d.seen, oldest.hash)
+		}
+		d.recent[d.next] = entry
+		d.next = (d.next + 1) % d.maxPeriod
+	}
+	d.seen[d.hash] = turn
+}
+
+// step updates the hash with the cells flipped to reach the given turn. It returns the period
+// if the world is the same as it was at most maxPeriod turns ago, or 0 if it is not.
+func (d *cycleDetector) step(flipped []util.Cell, turn int) int {
+	for _, cell := range flipped {
+		d.hash ^= cellKey(cell.Y*d.width + cell.X)
+	}
+	period := 0
+	if previous, ok := d.seen[d.hash]; ok && turn-previous <= d.maxPeriod && d.sameSince(previous, flipped) {
+		period = turn - previous
+	}
+	d.add(turn, flipped)
+	return period
+}
+
+// sameSince checks that every cell flipped after the given turn, including the flipped cells of this turn,
+// has been flipped an even number of times, so that the hashes did not just collide.
+func (d *cycleDetector) sameSince(turn int, flipped []util.Cell) bool {
+	diff := newBitGrid(d.height, d.width)
+	toggle := func(cells []util.Cell) {
+		for _, cell := range cells {
+			diff.set(cell.Y, cell.X, !diff.get(cell.Y, cell.X))
+		}
+	}
+	toggle(flipped)
+	for _, entry := range d.recent {
+		if entry.turn > turn {
+			toggle(entry.flipped)
+		}
+	}
+	return diff.aliveCellCount() == 0
+}
@@ -3,7 +3,9 @@ package gol
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
+	"uk.ac.bris.cs/gameoflife/census"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -14,18 +16,84 @@ type distributorChannels struct {
 	ioFilename chan<- string
 	ioOutput   chan<- uint8
 	ioInput    <-chan uint8
+	ioError    <-chan error
 	keyPresses <-chan rune
+	edits      <-chan util.Cell
+
+	checkpointOutput chan<- checkpoint
+	checkpointInput  <-chan checkpoint
+	statsOutput      chan<- []turnStats
 }
 
-func saveWorldAsImage(c distributorChannels, name string, height, width, turns int, world [][]uint8) {
+// saveWorldAsImage sends the world to the io goroutine and returns the result of writing it.
+func saveWorldAsImage(c distributorChannels, name string, turns int, world *bitGrid) error {
 	fmt.Println("Saving...")
 	c.ioCommand <- ioOutput
 	c.ioFilename <- name + "x" + strconv.Itoa(turns)
-	for row := 0; row < height; row++ {
-		for col := 0; col < width; col++ {
-			c.ioOutput <- world[row][col]
+	for row := 0; row < world.height; row++ {
+		for col := 0; col < world.width; col++ {
+			if world.get(row, col) {
+				c.ioOutput <- 255
+			} else {
+				c.ioOutput <- 0
+			}
+		}
+	}
+	return <-c.ioError
+}
+
+// saveCheckpoint sends the full state of the simulation to the io goroutine and returns the result of writing it.
+func saveCheckpoint(c distributorChannels, name string, p Params, rule Rule, turns int, world *bitGrid) error {
+	c.ioCommand <- ioCheckpointOutput
+	c.ioFilename <- name + "x" + strconv.Itoa(turns)
+	c.checkpointOutput <- newCheckpoint(p, rule, turns, world)
+	return <-c.ioError
+}
+
+// saveWorldAsPattern sends the world to the io goroutine to be written in the pattern format
+// named by the extension and returns the result of writing it.
+func saveWorldAsPattern(c distributorChannels, name, extension string, p Params, rule Rule, turns int, world *bitGrid) error {
+	c.ioCommand <- ioPatternOutput
+	c.ioFilename <- name + "x" + strconv.Itoa(turns) + "." + extension
+	c.checkpointOutput <- newCheckpoint(p, rule, turns, world)
+	return <-c.ioError
+}
+
+// saveWorld writes the world as a pgm image followed by every pattern format in the params.
+func saveWorld(c distributorChannels, name string, p Params, rule Rule, turns int, world *bitGrid) error {
+	if err := saveWorldAsImage(c, name, turns, world); err != nil {
+		return err
+	}
+	for _, extension := range p.OutputFormats {
+		if err := saveWorldAsPattern(c, name, extension, p, rule, turns, world); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// saveSnapshot writes everything 's' saves: the pgm image, the pattern formats in the params and a checkpoint.
+func saveSnapshot(c distributorChannels, name string, p Params, rule Rule, turns int, world *bitGrid) error {
+	if err := saveWorld(c, name, p, rule, turns, world); err != nil {
+		return err
+	}
+	return saveCheckpoint(c, name, p, rule, turns, world)
+}
+
+// loadCheckpoint requests a checkpoint from the io goroutine and sends a CellFlipped event for every alive cell.
+func loadCheckpoint(c distributorChannels, path string) (*bitGrid, int, error) {
+	c.ioCommand <- ioCheckpointInput
+	c.ioFilename <- path
+	if err := <-c.ioError; err != nil {
+		return nil, 0, err
+	}
+	cp := <-c.checkpointInput
+
+	world := cp.world()
+	for _, cell := range world.aliveCells() {
+		c.events <- CellFlipped{cp.Turn, cell}
+	}
+	return world, cp.Turn, nil
 }
 
 func quitExecution(c distributorChannels, turns int) {
@@ -36,241 +104,532 @@ func quitExecution(c distributorChannels, turns int) {
 	close(c.events)
 }
 
-// makeMatrix allocates memory for a matrix
-func makeMatrix(height, width int) [][]uint8 {
-	matrix := make([][]uint8, height)
-	for i := range matrix {
-		matrix[i] = make([]uint8, width)
-	}
-	return matrix
+// recordFrame sends the world to the io goroutine to be added to the recording and returns the result of adding it.
+func recordFrame(c distributorChannels, p Params, rule Rule, turns int, world *bitGrid) error {
+	c.ioCommand <- ioFrameOutput
+	c.checkpointOutput <- newCheckpoint(p, rule, turns, world)
+	return <-c.ioError
 }
 
-func calcAliveCellCount(height, width int, world [][]byte) int {
-	var count int
-	for row := 0; row < height; row++ {
-		for col := 0; col < width; col++ {
-			if world[row][col] == 255 {
-				count++
-			}
-		}
-	}
-	return count
+// writeStats sends a batch of rows of statistics to the io goroutine to be appended to the file and returns the result of writing them.
+func writeStats(c distributorChannels, rows []turnStats) error {
+	c.ioCommand <- ioStatsOutput
+	c.statsOutput <- rows
+	return <-c.ioError
 }
 
-func calcAliveCells(world [][]byte, height, width int) []util.Cell {
-	var cells []util.Cell
-	for row := 0; row < height; row++ {
-		for col := 0; col < width; col++ {
-			if world[row][col] == 255 {
-				c := util.Cell{X: col, Y: row}
-				cells = append(cells, c)
-			}
-		}
+// saveStats writes the rows of statistics that are left, if there are any, and closes the file.
+func saveStats(c distributorChannels, stats *statsCollector) error {
+	if stats == nil {
+		return nil
+	}
+	if err := writeStats(c, stats.rest()); err != nil {
+		return err
 	}
-	return cells
+	return closeStats(c)
 }
 
-// pauseLoop infinite loop waiting on another 'p' key press
-func pauseLoop(pause chan bool, c distributorChannels, name string, height, width, turns int, world [][]byte) {
-	for {
-		k := <-c.keyPresses
-		if k == 'p' {
-			pause <- true
-			break
-		} else if k == 's' {
-			saveWorldAsImage(c, name, height, width, turns, world)
-		} else if k == 'q' {
-			quitExecution(c, turns)
-			break
-		}
-	}
+// closeStats asks the io goroutine to close the file of statistics, if there is one, and returns the result of closing it.
+func closeStats(c distributorChannels) error {
+	c.ioCommand <- ioStatsClose
+	return <-c.ioError
 }
 
-func worker(startY, endY int, worldIn [][]byte, out chan<- [][]uint8, p Params) {
-	boardSeg := updateBoard(startY, endY, worldIn, p)
-	out <- boardSeg
+// closeRecording asks the io goroutine to finish the recording, if there is one, and returns the result of writing it.
+func closeRecording(c distributorChannels) error {
+	c.ioCommand <- ioRecordingClose
+	return <-c.ioError
 }
 
-// UpdateBoard updates and returns a single iteration of GOL
-func updateBoard(startY, endY int, worldIn [][]byte, p Params) [][]byte {
-	segHeight := endY - startY
+// quitWithError finishes the recording and the statistics, sends an ErrorOccurred event if err is not nil and then quits.
+func quitWithError(c distributorChannels, turns int, err error) {
+	if closeErr := closeRecording(c); err == nil {
+		err = closeErr
+	}
+	if closeErr := closeStats(c); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		c.events <- ErrorOccurred{turns, err}
+	}
+	quitExecution(c, turns)
+}
 
-	// initialise worldOut with dead cells
-	worldOut := makeMatrix(segHeight, p.ImageWidth)
-	for row := 0; row < segHeight; row++ {
-		for col := 0; col < p.ImageWidth; col++ {
-			worldOut[row][col] = 0
+// pauseLoop waits for another 'p' key press and returns the key that ended the pause, which is 'p', 'q' or 'k'.
+// While paused, 's' saves the world, edits toggle cells, 'n' steps forward a single turn
+// and 'b' undoes the last edit or turn kept in the history.
+// The engine is restarted from the world before leaving if it has been edited or stepped back.
+func pauseLoop(c distributorChannels, name string, p Params, rule Rule, engine *stepper, turn *int, hist *history) (rune, error) {
+	world, err := (*engine).world()
+	if err != nil {
+		return 0, err
+	}
+	// stale is set once the world no longer matches the one in the engine
+	stale := false
+	restart := func() error {
+		if !stale {
+			return nil
+		}
+		(*engine).stop()
+		var err error
+		*engine, err = startStepper(world, p, rule, *turn)
+		if err == nil {
+			// the engine owns the world from now on
+			world, err = (*engine).world()
+			stale = false
 		}
+		return err
 	}
 
-	for row := startY; row < endY; row++ {
-		for col := 0; col < p.ImageWidth; col++ {
-			// CURRENT ELEMENT AND ITS NEIGHBOR COUNT RESET
-			element := worldIn[row][col]
-			counter := 0
-
-			// iterate through all neighbors of given element
-			for dy := -1; dy <= 1; dy++ {
-				for dx := -1; dx <= 1; dx++ {
-					nRow := (row + dx + p.ImageHeight) % p.ImageHeight
-					nCol := (col + dy + p.ImageWidth) % p.ImageWidth
-					// increment counter if given neighbor is alive
-					if worldIn[nRow][nCol] == 255 {
-						counter++
-					}
+	for {
+		select {
+		case k := <-c.keyPresses:
+			switch k {
+			case 'p', 'q', 'k':
+				return k, restart()
+			case 's':
+				if err := saveSnapshot(c, name, p, rule, *turn, world); err != nil {
+					return k, err
+				}
+			case 'n':
+				if *turn >= p.Turns {
+					continue
+				}
+				if err := restart(); err != nil {
+					return k, err
+				}
+				flipped, completed, err := (*engine).step(1)
+				if err != nil {
+					return k, err
+				}
+				if hist != nil {
+					hist.push(*turn, flipped)
+				}
+				*turn += completed
+				if world, err = (*engine).world(); err != nil {
+					return k, err
+				}
+				for _, cell := range flipped {
+					c.events <- CellFlipped{*turn, cell}
+				}
+				c.events <- TurnComplete{*turn}
+			case 'b':
+				if hist == nil {
+					continue
 				}
+				flipped, previousTurn, ok := hist.pop()
+				if !ok {
+					fmt.Println("No earlier turns kept.")
+					continue
+				}
+				*turn = previousTurn
+				stale = true
+				for _, cell := range flipped {
+					world.set(cell.Y, cell.X, !world.get(cell.Y, cell.X))
+					c.events <- CellFlipped{*turn, cell}
+				}
+				c.events <- TurnComplete{*turn}
 			}
-
-			// if element is alive exclude it from the counter
-			if element == 255 {
-				counter--
+		case cell := <-c.edits:
+			if cell.X < 0 || cell.X >= world.width || cell.Y < 0 || cell.Y >= world.height {
+				continue
 			}
+			world.set(cell.Y, cell.X, !world.get(cell.Y, cell.X))
+			stale = true
+			if hist != nil {
+				hist.push(*turn, []util.Cell{cell})
+			}
+			c.events <- CellFlipped{*turn, cell}
+		}
+	}
+}
 
-			superRow := row - startY
+// stepper calculates new turns for the distributor.
+type stepper interface {
+	// step completes between 1 and maxTurns turns and returns the flipped cells in row-major order
+	// along with the number of turns completed.
+	step(maxTurns int) ([]util.Cell, int, error)
+	// world returns the current state of the world.
+	world() (*bitGrid, error)
+	aliveCellCount() int
+	stop()
+}
 
-			// if element is dead
-			if element == 0 {
-				if counter == 3 {
-					worldOut[superRow][col] = 255
-				} else {
-					worldOut[superRow][col] = 0
-				}
-			} else {
-				// if element is alive
-				if counter < 2 {
-					worldOut[superRow][col] = 0
-				} else if counter > 3 {
-					worldOut[superRow][col] = 0
-				} else {
-					worldOut[superRow][col] = 255
-				}
-			}
+// startStepper starts the engine selected by the params on the world at the given turn.
+func startStepper(world *bitGrid, p Params, rule Rule, turn int) (stepper, error) {
+	if p.Broker != "" {
+		broker, err := dialBroker(world, p, turn)
+		if err != nil {
+			// a nil *brokerStepper would not be a nil stepper
+			return nil, err
+		}
+		return broker, nil
+	}
+	if p.Engine == HashLife {
+		if p.Topology != Torus {
+			return nil, fmt.Errorf("the %v engine only supports the %v topology", HashLife, Torus)
 		}
+		return newHashLife(world, rule, p.HashLifeStep), nil
 	}
-	return worldOut
+	return startWorkers(world, p, rule), nil
 }
 
-// distributor divides the work between workers and interacts with other goroutines.
-func distributor(p Params, c distributorChannels) {
+// readWorld loads the image, or makes the soup, and sends a CellFlipped event for every alive cell.
+func readWorld(p Params, c distributorChannels, name string) (*bitGrid, error) {
 	// 	INPUT operations
-	name := strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight)
 	c.ioCommand <- ioInput
-	c.ioFilename <- name
+	if p.Soup.Density > 0 {
+		c.ioFilename <- p.Soup.String()
+	} else if p.Input != "" {
+		c.ioFilename <- p.Input
+	} else {
+		c.ioFilename <- "images/" + name + ".pgm"
+	}
+	if err := <-c.ioError; err != nil {
+		return nil, err
+	}
 
-	worldIn := makeMatrix(p.ImageHeight, p.ImageWidth)
-	// get image byte by byte and store in: worldIn
+	world := newBitGrid(p.ImageHeight, p.ImageWidth)
+	// get image byte by byte and store in: world
 	for row := 0; row < p.ImageHeight; row++ {
 		for col := 0; col < p.ImageWidth; col++ {
-			worldIn[row][col] = <-c.ioInput
-			if worldIn[row][col] != 0 {
+			if <-c.ioInput != 0 {
+				world.set(row, col, true)
 				c.events <- CellFlipped{Cell: util.Cell{X: col, Y: row}}
 			}
 		}
 	}
+	return world, nil
+}
 
-	// initialise worldOut as worldIn for turn 0
-	worldOut := makeMatrix(p.ImageHeight, p.ImageWidth)
-	for row := 0; row < p.ImageHeight; row++ {
-		for col := 0; col < p.ImageWidth; col++ {
-			worldOut[row][col] = worldIn[row][col]
+// distributor divides the work between workers and interacts with other goroutines.
+func distributor(p Params, c distributorChannels) {
+	rule, err := ParseRule(p.Rule)
+	if err == nil {
+		_, err = ParseFormats(strings.Join(p.OutputFormats, ","))
+	}
+	if err == nil && p.Record.Path != "" {
+		err = p.Record.check()
+	}
+	if err != nil {
+		quitWithError(c, 0, err)
+		return
+	}
+
+	name := strconv.Itoa(p.ImageWidth) + "x" + strconv.Itoa(p.ImageHeight)
+	var world *bitGrid
+	var engine stepper
+	turn := 0
+	if p.Attach {
+		// replay the live world so that it can be drawn
+		engine, world, turn, err = attachBroker(p)
+		if err != nil {
+			quitWithError(c, 0, err)
+			return
+		}
+		for _, cell := range world.aliveCells() {
+			c.events <- CellFlipped{turn, cell}
+		}
+	} else {
+		if p.Resume != "" {
+			world, turn, err = loadCheckpoint(c, p.Resume)
+		} else {
+			world, err = readWorld(p, c, name)
+		}
+		if err == nil {
+			// the engine owns the world from now on
+			engine, err = startStepper(world, p, rule, turn)
+		}
+		if err != nil {
+			quitWithError(c, turn, err)
+			return
+		}
+	}
+
+	// the recording starts with the world as it was loaded
+	recordEvery := p.Record.Every
+	if recordEvery < 1 {
+		recordEvery = 1
+	}
+	if p.Record.Path != "" {
+		if err := recordFrame(c, p, rule, turn, world); err != nil {
+			engine.stop()
+			quitWithError(c, turn, err)
+			return
+		}
+	}
+
+	// a broker runs ahead by itself, so only local engines keep a history
+	var hist *history
+	if p.History > 0 && p.Broker == "" {
+		hist = newHistory(p.History)
+	}
+
+	pace := &speed{rate: p.TurnsPerSecond}
+
+	// the statistics and cycles start from the world as the engine has it
+	if p.Stats != "" || p.DetectCycles > 0 {
+		if world, err = engine.world(); err != nil {
+			engine.stop()
+			quitWithError(c, turn, err)
+			return
+		}
+	}
+
+	var stats *statsCollector
+	if p.Stats != "" {
+		// rows the history can still step back over are held back
+		keep := 0
+		if hist != nil {
+			keep = p.History
 		}
+		stats = newStatsCollector(world, keep)
 	}
 
-	timeOver := time.NewTicker(2 * time.Second)
-	pause := make(chan bool, 1)
-	quit := false
+	// period is the period of the cycle the world is in, or 0 if none has been found yet
+	var cycles *cycleDetector
+	period := 0
+	if p.DetectCycles > 0 {
+		cycles = newCycleDetector(world, turn, p.DetectCycles)
+	}
+
+	// AliveCellsCount is sent on a timer unless it is sent every few turns
+	var aliveTicks <-chan time.Time
+	if p.AliveCellsTurns <= 0 && p.AliveCellsPeriod >= 0 {
+		interval := p.AliveCellsPeriod
+		if interval == 0 {
+			interval = 2 * time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		aliveTicks = ticker.C
+	}
 	var key rune
-	turn := 0
 
-	// TODO: Execute all turns of the Game of Life.
 	for turn < p.Turns {
 		select {
-		case <-timeOver.C:
-			if !quit {
-				c.events <- AliveCellsCount{turn, calcAliveCellCount(p.ImageHeight, p.ImageWidth, worldOut)}
-			}
+		case <-aliveTicks:
+			c.events <- AliveCellsCount{turn, engine.aliveCellCount()}
+		case <-c.edits:
+			// edits only apply while paused
 		case key = <-c.keyPresses:
-			switch key {
-			case 'p':
+			if key == 'p' {
 				fmt.Println("Paused. Current turn:", turn)
-				go pauseLoop(pause, c, name, p.ImageHeight, p.ImageWidth, turn, worldOut)
 				c.events <- StateChange{turn, Paused}
-				_ = <-pause
-				c.events <- StateChange{turn, Executing}
-				fmt.Println("Continuing.")
+				key, err = pauseLoop(c, name, p, rule, &engine, &turn, hist)
+				if err != nil {
+					// a failed restart leaves no engine to stop
+					if engine != nil {
+						engine.stop()
+					}
+					quitWithError(c, turn, err)
+					return
+				}
+				if key == 'p' && (cycles != nil || stats != nil) {
+					// the world may have been edited or stepped while paused
+					if world, err = engine.world(); err != nil {
+						engine.stop()
+						quitWithError(c, turn, err)
+						return
+					}
+					if cycles != nil {
+						cycles.reset(world, turn)
+						period = 0
+					}
+					if stats != nil {
+						stats.rewind(world, turn)
+					}
+				}
+				if key == 'p' {
+					c.events <- StateChange{turn, Executing}
+					fmt.Println("Continuing.")
+				}
+			}
+			switch key {
+			case '+', '-':
+				if key == '+' {
+					pace.faster()
+				} else {
+					pace.slower()
+				}
+				fmt.Println("Speed:", pace)
 			case 's':
-				saveWorldAsImage(c, name, p.ImageHeight, p.ImageWidth, p.Turns, worldOut)
+				world, err = engine.world()
+				if err == nil {
+					err = saveSnapshot(c, name, p, rule, turn, world)
+				}
+				if err != nil {
+					engine.stop()
+					quitWithError(c, turn, err)
+					return
+				}
 			case 'q':
-				quit = true
-				saveWorldAsImage(c, name, p.ImageHeight, p.ImageWidth, p.Turns, worldOut)
-				quitExecution(c, turn)
+				// a broker carries on by itself, a local engine just stops
+				world, err = engine.world()
+				if err == nil {
+					err = saveWorld(c, name, p, rule, p.Turns, world)
+				}
+				if err == nil {
+					err = saveStats(c, stats)
+				}
+				if broker, ok := engine.(*brokerStepper); ok {
+					if detachErr := broker.detach(); err == nil {
+						err = detachErr
+					}
+				} else {
+					engine.stop()
+				}
+				quitWithError(c, turn, err)
+				return
+			case 'k':
+				// a broker shuts down along with its workers, a local engine just stops
+				world, err = engine.world()
+				if err == nil {
+					err = saveWorld(c, name, p, rule, turn, world)
+				}
+				if err == nil {
+					err = saveStats(c, stats)
+				}
+				if broker, ok := engine.(*brokerStepper); ok {
+					if shutdownErr := broker.shutdown(); err == nil {
+						err = shutdownErr
+					}
+				} else {
+					engine.stop()
+				}
+				quitWithError(c, turn, err)
+				return
 			}
 		default:
-			if quit {
-				break
+			if wait := pace.wait(); wait > 0 {
+				// wait in short slices so that key presses are still picked up
+				if wait > 10*time.Millisecond {
+					wait = 10 * time.Millisecond
+				}
+				time.Sleep(wait)
+				continue
 			}
-			if p.Threads == 1 {
-				worldOut = updateBoard(0, p.ImageHeight, worldIn, p)
-			} else {
-				out := make([]chan [][]uint8, p.Threads)
-
-				for i := range out {
-					out[i] = make(chan [][]uint8)
+			maxTurns := p.Turns - turn
+			if pace.rate > 0 {
+				// a limited speed reports every turn
+				maxTurns = 1
+			}
+			if p.AliveCellsTurns > 0 {
+				// stop on the next multiple so that the count is for exactly that turn
+				if left := p.AliveCellsTurns - turn%p.AliveCellsTurns; left < maxTurns {
+					maxTurns = left
 				}
-
-				SmallHeight := p.ImageHeight / p.Threads
-				BigHeight := SmallHeight + 1
-				counter := 0
-
-				for i := 0; i < p.ImageHeight%p.Threads; i++ {
-					go worker(i*BigHeight, (i+1)*BigHeight, worldIn, out[i], p)
-					counter++
+			}
+			if p.FastForward && period > 0 {
+				// only run as many turns as needed to line the cycle up with the last turn
+				if left := (p.Turns - turn) % period; left > 0 && left < maxTurns {
+					maxTurns = left
 				}
+			}
+			flipped, completed, err := engine.step(maxTurns)
+			if err != nil {
+				engine.stop()
+				quitWithError(c, turn, err)
+				return
+			}
+			if hist != nil {
+				hist.push(turn, flipped)
+			}
+			pace.stepped(completed)
+			turn += completed
 
-				start := (counter) * BigHeight
-				end := start + SmallHeight
+			// send a CellFlipped event for every cell that has changed
+			for _, cell := range flipped {
+				c.events <- CellFlipped{turn, cell}
+			}
 
-				for j := p.ImageHeight % p.Threads; j < p.Threads; j++ {
-					go worker(start, end, worldIn, out[j], p)
-					start = start + SmallHeight
-					end = end + SmallHeight
-				}
+			c.events <- TurnComplete{turn}
 
-				worldOut = makeMatrix(0, 0)
+			if p.AliveCellsTurns > 0 && turn/p.AliveCellsTurns != (turn-completed)/p.AliveCellsTurns {
+				c.events <- AliveCellsCount{turn, engine.aliveCellCount()}
+			}
 
-				for i := 0; i < p.Threads; i++ {
-					part := <-out[i]
-					worldOut = append(worldOut, part...)
+			if stats != nil {
+				stats.add(turn, flipped)
+				if rows := stats.ready(); rows != nil {
+					if err := writeStats(c, rows); err != nil {
+						engine.stop()
+						quitWithError(c, turn, err)
+						return
+					}
 				}
 			}
 
-			turn++
+			if cycles != nil && period == 0 {
+				if period = cycles.step(flipped, turn); period > 0 {
+					fmt.Println("Cycle of period", period, "found at turn", turn)
+					c.events <- CycleDetected{turn, period}
+				}
+			}
 
-			// check which cells have changed, and send CellFlipped event
-			for row := 0; row < p.ImageHeight; row++ {
-				for col := 0; col < p.ImageWidth; col++ {
-					if worldOut[row][col] != worldIn[row][col] {
-						c.events <- CellFlipped{turn, util.Cell{X: col, Y: row}}
-					}
+			// add a frame to the recording whenever the turn passes a multiple of its interval
+			if p.Record.Path != "" && turn/recordEvery != (turn-completed)/recordEvery {
+				world, err = engine.world()
+				if err == nil {
+					err = recordFrame(c, p, rule, turn, world)
+				}
+				if err != nil {
+					engine.stop()
+					quitWithError(c, turn, err)
+					return
 				}
 			}
 
-			// worldIn = worldOut before you move onto the next iteration
-			for row := 0; row < p.ImageHeight; row++ {
-				for col := 0; col < p.ImageWidth; col++ {
-					worldIn[row][col] = worldOut[row][col]
+			// write a checkpoint whenever the turn passes a multiple of the interval
+			if p.CheckpointInterval > 0 && turn/p.CheckpointInterval != (turn-completed)/p.CheckpointInterval {
+				world, err = engine.world()
+				if err == nil {
+					err = saveCheckpoint(c, name, p, rule, turn, world)
+				}
+				if err != nil {
+					engine.stop()
+					quitWithError(c, turn, err)
+					return
 				}
 			}
 
-			c.events <- TurnComplete{turn}
+			// the world at the last turn is the same as now once the cycle lines up with it
+			if p.FastForward && period > 0 && turn < p.Turns && (p.Turns-turn)%period == 0 {
+				fmt.Println("Skipping to turn", p.Turns)
+				turn = p.Turns
+				c.events <- TurnComplete{turn}
+			}
 		}
 	}
 
-	// count final worldOut's state
-	cells := calcAliveCells(worldIn, p.ImageHeight, p.ImageWidth)
+	world, err = engine.world()
+	engine.stop()
+	if err != nil {
+		quitWithError(c, turn, err)
+		return
+	}
+
+	// count final world's state
+	cells := world.aliveCells()
 
 	// OUTPUT operations
-	saveWorldAsImage(c, name, p.ImageHeight, p.ImageWidth, turn, worldIn)
+	err = saveWorld(c, name, p, rule, turn, world)
+	if err == nil {
+		err = saveStats(c, stats)
+	}
+	if err == nil {
+		err = closeRecording(c)
+	}
+	if err != nil {
+		quitWithError(c, turn, err)
+		return
+	}
+
+	if p.Census {
+		counts := census.Take(world.width, world.height, p.Topology.Wrap(world.width, world.height), census.Rule(rule), cells)
+		c.events <- CensusComplete{turn, counts}
+	}
 
 	// TODO: Report the final state using FinalTurnCompleteEvent.
 	c.events <- FinalTurnComplete{p.Turns, cells}
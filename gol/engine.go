@@ -0,0 +1,35 @@
+package gol
+
+import "fmt"
+
+// Engine selects how the distributor calculates new turns.
+type Engine int
+
+const (
+	// RowStrips splits the world into horizontal strips, one per worker, and calculates one turn at a time.
+	RowStrips Engine = iota
+	// HashLife uses a memoised quadtree and can jump 2^HashLifeStep turns at once. It only supports Torus.
+	HashLife
+)
+
+var engineNames = map[Engine]string{
+	RowStrips: "strips",
+	HashLife:  "hashlife",
+}
+
+// ParseEngine returns the Engine with the given name, as printed by Engine.String.
+func ParseEngine(s string) (Engine, error) {
+	for engine, name := range engineNames {
+		if name == s {
+			return engine, nil
+		}
+	}
+	return RowStrips, fmt.Errorf("unknown engine %q", s)
+}
+
+func (engine Engine) String() string {
+	if name, ok := engineNames[engine]; ok {
+		return name
+	}
+	return "Incorrect Engine"
+}
@@ -2,6 +2,7 @@ package gol
 
 import (
 	"fmt"
+	"uk.ac.bris.cs/gameoflife/census"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -15,7 +16,7 @@ type Event interface {
 }
 
 // AliveCellsCount is an Event notifying the user about the number of currently alive cells.
-// This Event should be sent every 2s.
+// This Event should be sent every 2s, or as often as Params.AliveCellsPeriod or Params.AliveCellsTurns ask for.
 type AliveCellsCount struct { // implements Event
 	CompletedTurns int
 	CellsCount     int
@@ -67,6 +68,29 @@ type FinalTurnComplete struct {
 	Alive          []util.Cell
 }
 
+// ErrorOccurred is an Event notifying the user that execution has stopped because of an error,
+// such as an image that could not be read. It is followed by a Quitting StateChange
+// and FinalTurnComplete is never sent.
+type ErrorOccurred struct {
+	CompletedTurns int
+	Err            error
+}
+
+// CycleDetected is an Event notifying the user that the world is the same as it was Period turns ago,
+// so it will repeat every Period turns from now on. A Period of 1 is a still life.
+// This Event is sent the first time a cycle is found, and again after a pause if there still is one.
+type CycleDetected struct {
+	CompletedTurns int
+	Period         int
+}
+
+// CensusComplete is an Event notifying the user about the objects on the final board,
+// counted by name such as "block" or "glider". It is sent before FinalTurnComplete if Params.Census is set.
+type CensusComplete struct {
+	CompletedTurns int
+	Counts         map[string]int
+}
+
 // String methods allow the different types of Events and States to be printed.
 
 func (state State) String() string {
@@ -130,6 +154,33 @@ func (event FinalTurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event ErrorOccurred) String() string {
+	return fmt.Sprintf("Error: %v", event.Err)
+}
+
+func (event ErrorOccurred) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event CycleDetected) String() string {
+	if event.Period == 1 {
+		return "Still life"
+	}
+	return fmt.Sprintf("Cycle of period %v", event.Period)
+}
+
+func (event CycleDetected) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event CensusComplete) String() string {
+	return fmt.Sprintf("Census: %v", census.Summary(event.Counts))
+}
+
+func (event CensusComplete) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 // This might all seem like weird syntax to you...
 // You have however seen something similar to it before in first year.
 
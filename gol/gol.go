@@ -1,15 +1,130 @@
 package gol
 
+import (
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
 	Turns       int
 	Threads     int
 	ImageWidth  int
 	ImageHeight int
+	Rule        string   // rulestring in B/S notation, defaults to ConwayRule
+	Topology    Topology // how the edges of the board are joined, defaults to Torus
+	Engine      Engine   // how new turns are calculated, defaults to RowStrips
+	// HashLifeStep is log2 of the most turns the HashLife engine may jump at once.
+	// Every jump is followed by a single TurnComplete event, so 0 reports every turn.
+	HashLifeStep int
+	// Broker is the address of a broker that runs the turns on its remote workers.
+	// If it is empty the turns are run locally by Engine.
+	Broker string
+	// Attach picks up the live world and turn from Broker instead of loading an image,
+	// after a previous controller quit and left the broker running.
+	Attach bool
+	// CheckpointInterval writes a checkpoint every CheckpointInterval turns, 0 only writes one on 's'.
+	CheckpointInterval int
+	// Resume is the path of a checkpoint to carry on from instead of loading an image.
+	// The size, rule and topology of the checkpoint replace the ones in these params.
+	Resume string
+	// Input is the path of a netpbm image or pattern to load instead of images/<width>x<height>.pgm.
+	// The size in an image header replaces the one in these params. A pattern is placed on the board,
+	// which is only as big as the pattern if no size is given, and its rule is used if Rule is empty.
+	// The format of a pattern is chosen by its extension: .rle, .cells, or .lif and .life for Life 1.06.
+	Input string
+	// Soup fills the board at random instead of loading an image or pattern if its Density is above 0.
+	Soup Soup
+	// Origin is the cell that (0, 0) of a Life 1.06 pattern is placed on, both when loading and saving.
+	// Other pattern formats are placed in the middle of the board.
+	Origin util.Cell
+	// Threshold is the fraction of the maxval of an image that a pixel has to be above to be alive.
+	// The default of 0 makes every non-zero pixel alive.
+	Threshold float64
+	// PBM writes images as 1-bit P4 .pbm files instead of P5 .pgm files.
+	PBM bool
+	// OutputFormats lists the extensions of the pattern formats written next to every pgm image, such as "cells".
+	OutputFormats []string
+	// Record writes an animation of the run if its Path is set.
+	Record Recording
+	// History is how many earlier steps and edits are kept so that 'b' can undo them while paused, 0 keeps none.
+	// Only the cells flipped by each step are kept, so HashLife jumps back as far as it jumped forward.
+	// Runs on a broker keep no history.
+	History int
+	// TurnsPerSecond limits how fast turns are run, 0 runs them as fast as possible.
+	// The limit can be changed while running with '+' and '-'.
+	TurnsPerSecond float64
+	// DetectCycles is the longest period of cycle to look for by hashing every generation, 0 does not look.
+	// A CycleDetected event is sent when the world is the same as it was up to DetectCycles turns ago.
+	DetectCycles int
+	// FastForward skips to the last turn once a cycle has been found, only running the turns needed
+	// for the final world to be the same as after a full run. The skipped turns are not reported or recorded.
+	FastForward bool
+	// Census counts the objects on the final board, such as blocks and gliders, and sends them in a CensusComplete event.
+	Census bool
+	// Stats is the path of a csv file to write the population, births, deaths, bounding box and density
+	// of every turn to, with the same first two columns as check/alive. Empty writes no stats.
+	// A HashLife jump is a single row, and turns stepped while paused are left out.
+	// Rows are appended in batches as the run goes, holding back as many as the History can step back over.
+	Stats string
+	// AliveCellsPeriod is the time between AliveCellsCount events, 0 keeps the default of 2 seconds.
+	// A negative period sends none.
+	AliveCellsPeriod time.Duration
+	// AliveCellsTurns sends AliveCellsCount every AliveCellsTurns turns instead of on a timer, 0 uses the timer.
+	// The engine stops on every multiple so that HashLife does not jump past one.
+	AliveCellsTurns int
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
+	RunWithEdits(p, events, keyPresses, nil)
+}
+
+// RunWithEdits is Run with a channel of cells to toggle. The edits are applied to the world while
+// the run is paused and sent back as CellFlipped events, they are ignored while it is executing.
+func RunWithEdits(p Params, events chan<- Event, keyPresses <-chan rune, edits <-chan util.Cell) {
+
+	// the size, rule and topology of a checkpoint replace the given ones
+	if p.Resume != "" {
+		resumed, _, err := ReadCheckpointParams(p.Resume)
+		if err != nil {
+			events <- ErrorOccurred{0, err}
+			events <- StateChange{0, Quitting}
+			close(events)
+			return
+		}
+		p.ImageWidth, p.ImageHeight = resumed.ImageWidth, resumed.ImageHeight
+		p.Rule, p.Topology = resumed.Rule, resumed.Topology
+	}
+
+	// the size of an input image replaces the given one,
+	// a pattern is placed on a board of the given size and only brings its rule along
+	if p.Input != "" && p.Resume == "" {
+		var header pattern
+		var err error
+		if IsPattern(p.Input) {
+			header, err = readPatternHeader(p.Input)
+		} else {
+			header.width, header.height, err = ReadImageSize(p.Input)
+		}
+		if err != nil {
+			events <- ErrorOccurred{0, err}
+			events <- StateChange{0, Quitting}
+			close(events)
+			return
+		}
+		if !IsPattern(p.Input) || p.ImageWidth <= 0 || p.ImageHeight <= 0 {
+			p.ImageWidth, p.ImageHeight = header.width, header.height
+			// a board the size of a relative pattern only fits it with its top left cell in the corner
+			if header.relative {
+				p.Origin = util.Cell{X: -header.minX, Y: -header.minY}
+			}
+		}
+		if p.Rule == "" {
+			p.Rule = header.rule
+		}
+	}
 
 	//	TODO: Put the missing channels in here.
 
@@ -18,6 +133,10 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 	ioFilename := make(chan string, 1)
 	ioOutput := make(chan uint8)
 	ioInput := make(chan uint8)
+	checkpointOutput := make(chan checkpoint)
+	checkpointInput := make(chan checkpoint)
+	ioError := make(chan error)
+	statsOutput := make(chan []turnStats)
 
 	ioChannels := ioChannels{
 		command:  ioCommand,
@@ -25,6 +144,11 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		filename: ioFilename,
 		output:   ioOutput,
 		input:    ioInput,
+
+		checkpointOutput: checkpointOutput,
+		checkpointInput:  checkpointInput,
+		statsOutput:      statsOutput,
+		err:              ioError,
 	}
 	go startIo(p, ioChannels)
 
@@ -36,6 +160,12 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		ioOutput:   ioOutput,
 		ioInput:    ioInput,
 		keyPresses: keyPresses,
+		edits:      edits,
+
+		checkpointOutput: checkpointOutput,
+		checkpointInput:  checkpointInput,
+		statsOutput:      statsOutput,
+		ioError:          ioError,
 	}
 	distributor(p, distributorChannels)
 }
@@ -0,0 +1,252 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// maxHashLifeNodes is the number of canonical nodes after which all memoised results are dropped.
+const maxHashLifeNodes = 1 << 22
+
+// node is a canonical quadtree node covering 2^level x 2^level cells.
+// Nodes are never modified once created, so equal subtrees share a single node.
+type node struct {
+	nw, ne, sw, se *node
+	level          int
+	population     int
+}
+
+// stepKey identifies a memoised result: the centre of n advanced by 2^log2Turns turns.
+type stepKey struct {
+	n         *node
+	log2Turns int
+}
+
+// buildKey identifies a node cut out of the periodic tiling of the world.
+type buildKey struct {
+	level, x, y int
+}
+
+// hashLife holds the canonical nodes and memoised results of the HashLife engine,
+// along with the current world.
+type hashLife struct {
+	rule         Rule
+	current      *bitGrid
+	maxLog2Turns int
+	dead         *node
+	alive        *node
+	nodes        map[[4]*node]*node
+	results      map[stepKey]*node
+}
+
+func newHashLife(world *bitGrid, rule Rule, maxLog2Turns int) *hashLife {
+	h := &hashLife{
+		rule:         rule,
+		current:      world,
+		maxLog2Turns: maxLog2Turns,
+		dead:         &node{level: 0, population: 0},
+		alive:        &node{level: 0, population: 1},
+	}
+	h.reset()
+	return h
+}
+
+// reset drops every canonical node and memoised result.
+func (h *hashLife) reset() {
+	h.nodes = make(map[[4]*node]*node)
+	h.results = make(map[stepKey]*node)
+}
+
+// join returns the canonical node with the given quadrants.
+func (h *hashLife) join(nw, ne, sw, se *node) *node {
+	key := [4]*node{nw, ne, sw, se}
+	if n, ok := h.nodes[key]; ok {
+		return n
+	}
+	n := &node{nw, ne, sw, se, nw.level + 1, nw.population + ne.population + sw.population + se.population}
+	h.nodes[key] = n
+	return n
+}
+
+// centre returns the node of half the size in the middle of n.
+func (h *hashLife) centre(n *node) *node {
+	return h.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// cell returns the state of the cell at (x, y) inside n.
+func (n *node) cell(x, y int) bool {
+	for n.level > 0 {
+		half := 1 << uint(n.level-1)
+		switch {
+		case x < half && y < half:
+			n = n.nw
+		case y < half:
+			n, x = n.ne, x-half
+		case x < half:
+			n, y = n.sw, y-half
+		default:
+			n, x, y = n.se, x-half, y-half
+		}
+	}
+	return n.population == 1
+}
+
+// baseStep advances the middle 2x2 cells of a 4x4 node by a single turn.
+func (h *hashLife) baseStep(n *node) *node {
+	var quadrants [4]*node
+	for i, offset := range [4][2]int{{1, 1}, {2, 1}, {1, 2}, {2, 2}} {
+		x, y := offset[0], offset[1]
+		counter := 0
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if (dx != 0 || dy != 0) && n.cell(x+dx, y+dy) {
+					counter++
+				}
+			}
+		}
+		if h.rule.next(n.cell(x, y), counter) {
+			quadrants[i] = h.alive
+		} else {
+			quadrants[i] = h.dead
+		}
+	}
+	return h.join(quadrants[0], quadrants[1], quadrants[2], quadrants[3])
+}
+
+// nextCentre returns the centre of n advanced by 2^log2Turns turns, where log2Turns <= n.level-2.
+func (h *hashLife) nextCentre(n *node, log2Turns int) *node {
+	key := stepKey{n, log2Turns}
+	if result, ok := h.results[key]; ok {
+		return result
+	}
+
+	var result *node
+	if n.level == 2 {
+		result = h.baseStep(n)
+	} else {
+		// nine overlapping sub-nodes of half the size
+		n00 := n.nw
+		n01 := h.join(n.nw.ne, n.ne.nw, n.nw.se, n.ne.sw)
+		n02 := n.ne
+		n10 := h.join(n.nw.sw, n.nw.se, n.sw.nw, n.sw.ne)
+		n11 := h.centre(n)
+		n12 := h.join(n.ne.sw, n.ne.se, n.se.nw, n.se.ne)
+		n20 := n.sw
+		n21 := h.join(n.sw.ne, n.se.nw, n.sw.se, n.se.sw)
+		n22 := n.se
+
+		if log2Turns == n.level-2 {
+			// advance twice by half the turns: once on the nine sub-nodes and once on the four results
+			half := log2Turns - 1
+			r00, r01, r02 := h.nextCentre(n00, half), h.nextCentre(n01, half), h.nextCentre(n02, half)
+			r10, r11, r12 := h.nextCentre(n10, half), h.nextCentre(n11, half), h.nextCentre(n12, half)
+			r20, r21, r22 := h.nextCentre(n20, half), h.nextCentre(n21, half), h.nextCentre(n22, half)
+			result = h.join(
+				h.nextCentre(h.join(r00, r01, r10, r11), half),
+				h.nextCentre(h.join(r01, r02, r11, r12), half),
+				h.nextCentre(h.join(r10, r11, r20, r21), half),
+				h.nextCentre(h.join(r11, r12, r21, r22), half),
+			)
+		} else {
+			// advance once on the nine sub-nodes and take the centres of the four combinations
+			r00, r01, r02 := h.nextCentre(n00, log2Turns), h.nextCentre(n01, log2Turns), h.nextCentre(n02, log2Turns)
+			r10, r11, r12 := h.nextCentre(n10, log2Turns), h.nextCentre(n11, log2Turns), h.nextCentre(n12, log2Turns)
+			r20, r21, r22 := h.nextCentre(n20, log2Turns), h.nextCentre(n21, log2Turns), h.nextCentre(n22, log2Turns)
+			result = h.join(
+				h.centre(h.join(r00, r01, r10, r11)),
+				h.centre(h.join(r01, r02, r11, r12)),
+				h.centre(h.join(r10, r11, r20, r21)),
+				h.centre(h.join(r11, r12, r21, r22)),
+			)
+		}
+	}
+
+	h.results[key] = result
+	return result
+}
+
+// build returns the node of the given level whose top left cell is at (x, y) of the world
+// repeated forever in every direction, which is how a torus looks from the inside.
+func (h *hashLife) build(world *bitGrid, level, x, y int, built map[buildKey]*node) *node {
+	x = (x%world.width + world.width) % world.width
+	y = (y%world.height + world.height) % world.height
+	if level == 0 {
+		if world.get(y, x) {
+			return h.alive
+		}
+		return h.dead
+	}
+
+	key := buildKey{level, x, y}
+	if n, ok := built[key]; ok {
+		return n
+	}
+	half := 1 << uint(level-1)
+	n := h.join(
+		h.build(world, level-1, x, y, built),
+		h.build(world, level-1, x+half, y, built),
+		h.build(world, level-1, x, y+half, built),
+		h.build(world, level-1, x+half, y+half, built),
+	)
+	built[key] = n
+	return n
+}
+
+// write sets the alive cells of n, whose top left cell is at (x, y), in world.
+// Cells beyond the edges of world are ignored.
+func (n *node) write(world *bitGrid, x, y int) {
+	if n.population == 0 || x >= world.width || y >= world.height {
+		return
+	}
+	if n.level == 0 {
+		world.set(y, x, true)
+		return
+	}
+	half := 1 << uint(n.level-1)
+	n.nw.write(world, x, y)
+	n.ne.write(world, x+half, y)
+	n.sw.write(world, x, y+half)
+	n.se.write(world, x+half, y+half)
+}
+
+// advance returns the world advanced by 2^log2Turns turns on a torus.
+func (h *hashLife) advance(world *bitGrid, log2Turns int) *bitGrid {
+	if len(h.nodes) > maxHashLifeNodes {
+		h.reset()
+	}
+
+	// the result is the centre of the node, so it must cover the world
+	// and the margin around it must be at least as wide as the number of turns
+	level := log2Turns + 2
+	for 1<<uint(level-1) < world.width || 1<<uint(level-1) < world.height {
+		level++
+	}
+
+	// shift the tiling so that the centre of the node starts at (0, 0) of the world
+	margin := 1 << uint(level-2)
+	root := h.build(world, level, -margin, -margin, make(map[buildKey]*node))
+	result := h.nextCentre(root, log2Turns)
+
+	next := newBitGrid(world.height, world.width)
+	result.write(next, 0, 0)
+	return next
+}
+
+// step jumps by the largest power of two allowed by maxLog2Turns and maxTurns.
+func (h *hashLife) step(maxTurns int) ([]util.Cell, int, error) {
+	log2Turns := 0
+	for log2Turns < h.maxLog2Turns && 1<<uint(log2Turns+1) <= maxTurns {
+		log2Turns++
+	}
+	next := h.advance(h.current, log2Turns)
+	flipped := next.flippedCells(h.current)
+	h.current = next
+	return flipped, 1 << uint(log2Turns), nil
+}
+
+func (h *hashLife) world() (*bitGrid, error) {
+	return h.current, nil
+}
+
+func (h *hashLife) aliveCellCount() int {
+	return h.current.aliveCellCount()
+}
+
+func (h *hashLife) stop() {}
@@ -0,0 +1,39 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// history is a ring of the most recent changes to the board, each one the cells it flipped
+// and the turn the board was on before it. Flipping the same cells again undoes a change.
+// Once it is full, pushing a change drops the oldest one.
+type history struct {
+	flipped [][]util.Cell
+	turns   []int
+	// next is the index the next change goes in, size is the number of changes kept
+	next, size int
+}
+
+func newHistory(capacity int) *history {
+	return &history{flipped: make([][]util.Cell, capacity), turns: make([]int, capacity)}
+}
+
+// push keeps the cells flipped by a change to the board that was at the given turn before it.
+func (h *history) push(turn int, flipped []util.Cell) {
+	h.flipped[h.next] = flipped
+	h.turns[h.next] = turn
+	h.next = (h.next + 1) % len(h.flipped)
+	if h.size < len(h.flipped) {
+		h.size++
+	}
+}
+
+// pop removes and returns the most recent change and the turn from before it, ok is false if there are none left.
+func (h *history) pop() (flipped []util.Cell, turn int, ok bool) {
+	if h.size == 0 {
+		return nil, 0, false
+	}
+	h.next = (h.next + len(h.flipped) - 1) % len(h.flipped)
+	h.size--
+	flipped, turn = h.flipped[h.next], h.turns[h.next]
+	h.flipped[h.next] = nil
+	return flipped, turn, true
+}
@@ -1,12 +1,22 @@
 package gol
 
 import (
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"strconv"
-	"strings"
-	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var (
+	// ErrNotPGM is returned when an image is not a P1, P2, P4 or P5 netpbm file.
+	ErrNotPGM = errors.New("not a pgm or pbm file")
+	// ErrDimensionMismatch is returned when the size of an image is not the size in the params.
+	ErrDimensionMismatch = errors.New("incorrect image size")
+	// ErrBitDepth is returned when the maxval of an image is outside 1 to 65535 or a pixel is above it.
+	ErrBitDepth = errors.New("incorrect maxval/bit depth")
+	// ErrTruncated is returned when an image holds fewer pixels than its header says.
+	ErrTruncated = errors.New("image data is truncated")
+	// ErrBadCheckpoint is returned when a checkpoint is not a checkpoint file or has been corrupted.
+	ErrBadCheckpoint = errors.New("bad checkpoint")
 )
 
 type ioChannels struct {
@@ -16,12 +26,21 @@ type ioChannels struct {
 	filename <-chan string
 	output   <-chan uint8
 	input    chan<- uint8
+
+	checkpointOutput <-chan checkpoint
+	checkpointInput  chan<- checkpoint
+	statsOutput      <-chan []turnStats
+
+	// err receives the result of every input and output command.
+	err chan<- error
 }
 
 // ioState is the internal ioState of the io goroutine.
 type ioState struct {
 	params   Params
 	channels ioChannels
+	recorder *recorder
+	stats    *statsWriter
 }
 
 // ioCommand allows requesting behaviour from the io (pgm) goroutine.
@@ -33,97 +52,168 @@ type ioCommand uint8
 //	ioOutput 	= 0
 //	ioInput 	= 1
 //	ioCheckIdle = 2
+//	ioCheckpointOutput = 3
+//	ioCheckpointInput  = 4
+//	ioPatternOutput    = 5
+//	ioFrameOutput      = 6
+//	ioRecordingClose   = 7
+//	ioStatsOutput      = 8
+//	ioStatsClose       = 9
 const (
 	ioOutput ioCommand = iota
 	ioInput
 	ioCheckIdle
+	ioCheckpointOutput
+	ioCheckpointInput
+	ioPatternOutput
+	ioFrameOutput
+	ioRecordingClose
+	ioStatsOutput
+	ioStatsClose
 )
 
-// writePgmImage receives an array of bytes and writes it to a pgm file.
+// writePgmImage receives an array of bytes and writes it to a pgm file, or a pbm file if the params ask for one.
+// It always receives the whole image, then sends the result of writing it on the error channel.
 func (io *ioState) writePgmImage() {
-	_ = os.Mkdir("out", os.ModePerm)
-
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	file, ioError := os.Create("out/" + filename + ".pgm")
-	util.Check(ioError)
-	defer file.Close()
-
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
-
-	world := make([][]byte, io.params.ImageHeight)
+	world := make([]byte, io.params.ImageWidth*io.params.ImageHeight)
 	for i := range world {
-		world[i] = make([]byte, io.params.ImageWidth)
+		world[i] = <-io.channels.output
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			val := <-io.channels.output
-			//if val != 0 {
-			//	fmt.Println(x, y)
-			//}
-			world[y][x] = val
-		}
+	if io.params.PBM {
+		io.channels.err <- writePbm("out/"+filename+".pbm", io.params.ImageWidth, io.params.ImageHeight, world)
+	} else {
+		io.channels.err <- writePgm("out/"+filename+".pgm", io.params.ImageWidth, io.params.ImageHeight, world)
 	}
+	fmt.Println("File", filename, "output done!")
+}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
-			util.Check(ioError)
-		}
+// readImage opens a netpbm image or pattern file and sends its data as an array of bytes.
+// The result of reading it is sent on the error channel first, the bytes only follow if it is nil.
+func (io *ioState) readImage() {
+	fmt.Println("reading...")
+	// Request a path from the distributor.
+	path := <-io.channels.filename
+
+	var image []byte
+	var err error
+	if io.params.Soup.Density > 0 {
+		image = io.params.Soup.image(io.params.ImageWidth, io.params.ImageHeight)
+	} else if IsPattern(path) {
+		image, err = readPattern(path, io.params.ImageWidth, io.params.ImageHeight, io.params.Origin)
+	} else {
+		image, err = readNetpbm(path, io.params.ImageWidth, io.params.ImageHeight, io.params.Threshold)
+	}
+	io.channels.err <- err
+	if err != nil {
+		return
 	}
 
-	ioError = file.Sync()
-	util.Check(ioError)
+	for _, b := range image {
+		io.channels.input <- b
+	}
 
-	fmt.Println("File", filename, "output done!")
+	fmt.Println("File", path, "input done!")
 }
 
-// readPgmImage opens a pgm file and sends its data as an array of bytes.
-func (io *ioState) readPgmImage() {
-	fmt.Println("reading...")
+// writeCheckpoint receives a checkpoint and writes it to a file next to the pgm images.
+func (io *ioState) writeCheckpoint() {
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
+	cp := <-io.channels.checkpointOutput
 
-	data, ioError := ioutil.ReadFile("images/" + filename + ".pgm")
-	util.Check(ioError)
+	io.channels.err <- writeCheckpointFile("out/"+filename+".checkpoint", cp)
+	fmt.Println("Checkpoint", filename, "output done!")
+}
+
+func writeCheckpointFile(path string, cp checkpoint) error {
+	_ = os.Mkdir("out", os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := encodeCheckpoint(file, cp); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// writePattern receives the state of the simulation as a checkpoint and writes its world
+// in the pattern format named by the extension of the filename.
+func (io *ioState) writePattern() {
+	// Request a filename from the distributor.
+	filename := <-io.channels.filename
+	cp := <-io.channels.checkpointOutput
 
-	fields := strings.Fields(string(data))
+	io.channels.err <- writePatternFile("out/"+filename, cp)
+	fmt.Println("File", filename, "output done!")
+}
 
-	if fields[0] != "P5" {
-		panic("Not a pgm file")
+// writeFrame receives the state of the simulation as a checkpoint and adds its world to the recording.
+func (io *ioState) writeFrame() {
+	cp := <-io.channels.checkpointOutput
+	if io.recorder == nil {
+		io.recorder = newRecorder(io.params.Record)
 	}
+	io.channels.err <- io.recorder.addFrame(cp.world(), cp.Turn)
+}
 
-	width, _ := strconv.Atoi(fields[1])
-	if width != io.params.ImageWidth {
-		panic("Incorrect width")
+// closeRecording finishes the recording if one has been started.
+func (io *ioState) closeRecording() {
+	var err error
+	if io.recorder != nil {
+		err = io.recorder.close()
+		io.recorder = nil
+		fmt.Println("Recording", io.params.Record.Path, "output done!")
 	}
+	io.channels.err <- err
+}
 
-	height, _ := strconv.Atoi(fields[2])
-	if height != io.params.ImageHeight {
-		panic("Incorrect height")
+// writeStats receives a batch of rows of statistics and appends them to the csv file in the params,
+// which is created by the first batch.
+func (io *ioState) writeStats() {
+	rows := <-io.channels.statsOutput
+	if io.stats == nil {
+		stats, err := newStatsWriter(io.params.Stats)
+		if err != nil {
+			io.channels.err <- err
+			return
+		}
+		io.stats = stats
 	}
+	io.channels.err <- io.stats.write(rows)
+}
 
-	maxval, _ := strconv.Atoi(fields[3])
-	if maxval != 255 {
-		panic("Incorrect maxval/bit depth")
+// closeStats closes the csv file of statistics if one has been started.
+func (io *ioState) closeStats() {
+	var err error
+	if io.stats != nil {
+		err = io.stats.close()
+		io.stats = nil
+		fmt.Println("Stats", io.params.Stats, "output done!")
 	}
+	io.channels.err <- err
+}
 
-	image := []byte(fields[4])
+// readCheckpoint opens a checkpoint file and sends it to the distributor.
+// The result of reading it is sent on the error channel first, the checkpoint only follows if it is nil.
+func (io *ioState) readCheckpoint() {
+	// Request a path from the distributor.
+	path := <-io.channels.filename
 
-	for _, b := range image {
-		io.channels.input <- b
+	cp, err := readCheckpointFile(path)
+	io.channels.err <- err
+	if err != nil {
+		return
 	}
+	io.channels.checkpointInput <- cp
 
-	fmt.Println("File", filename, "input done!")
+	fmt.Println("Checkpoint", path, "input done!")
 }
 
 // startIo should be the entrypoint of the io goroutine.
@@ -139,11 +229,25 @@ func startIo(p Params, c ioChannels) {
 		case command := <-io.channels.command:
 			switch command {
 			case ioInput:
-				io.readPgmImage()
+				io.readImage()
 			case ioOutput:
 				io.writePgmImage()
 			case ioCheckIdle:
 				io.channels.idle <- true
+			case ioCheckpointOutput:
+				io.writeCheckpoint()
+			case ioCheckpointInput:
+				io.readCheckpoint()
+			case ioPatternOutput:
+				io.writePattern()
+			case ioFrameOutput:
+				io.writeFrame()
+			case ioRecordingClose:
+				io.closeRecording()
+			case ioStatsOutput:
+				io.writeStats()
+			case ioStatsClose:
+				io.closeStats()
 			}
 		}
 	}
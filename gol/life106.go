@@ -0,0 +1,73 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// life106Header is the first line of every Life 1.06 file.
+const life106Header = "#Life 1.06"
+
+// decodeLife106 reads a Life 1.06 file, which lists the x and y of every alive cell on its own line.
+// The coordinates are relative to the origin and may be negative.
+// There is no size in the header, so if headerOnly is true the bounds are found without keeping the cells.
+func decodeLife106(r io.Reader, headerOnly bool) (pattern, error) {
+	p := pattern{relative: true}
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != life106Header {
+		if err := scanner.Err(); err != nil {
+			return p, err
+		}
+		return p, fmt.Errorf("%w: missing %q header", ErrBadPattern, life106Header)
+	}
+
+	minX, minY, maxX, maxY := 0, 0, -1, -1
+	count := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return p, fmt.Errorf("%w: bad coordinates %q", ErrBadPattern, line)
+		}
+		x, errX := strconv.Atoi(fields[0])
+		y, errY := strconv.Atoi(fields[1])
+		if errX != nil || errY != nil {
+			return p, fmt.Errorf("%w: bad coordinates %q", ErrBadPattern, line)
+		}
+		if count == 0 || x < minX {
+			minX = x
+		}
+		if count == 0 || y < minY {
+			minY = y
+		}
+		if count == 0 || x > maxX {
+			maxX = x
+		}
+		if count == 0 || y > maxY {
+			maxY = y
+		}
+		count++
+		if !headerOnly {
+			p.alive = append(p.alive, [2]int{x, y})
+		}
+	}
+	p.width, p.height = maxX-minX+1, maxY-minY+1
+	p.minX, p.minY = minX, minY
+	return p, scanner.Err()
+}
+
+// encodeLife106 writes the alive cells of the world in row-major order relative to the origin.
+func encodeLife106(w io.Writer, world *bitGrid, p Params) error {
+	buffered := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(buffered, "%s\n#D rule = %s\n", life106Header, p.Rule)
+	for _, cell := range world.aliveCells() {
+		_, _ = fmt.Fprintf(buffered, "%d %d\n", cell.X-p.Origin.X, cell.Y-p.Origin.Y)
+	}
+	return buffered.Flush()
+}
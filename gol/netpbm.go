@@ -0,0 +1,261 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// pgmHeader holds the fields at the start of a netpbm file.
+// The maxval of the 1-bit P1 and P4 formats is always 1.
+type pgmHeader struct {
+	magic                 string
+	width, height, maxval int
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// skipComment consumes the rest of a comment line.
+func skipComment(r *bufio.Reader) error {
+	_, err := r.ReadBytes('\n')
+	return err
+}
+
+// readToken returns the next whitespace separated token, skipping comments that run from '#' to the end of the line.
+// The single whitespace character or comment after the token is consumed as well.
+func readToken(r *bufio.Reader) (string, error) {
+	var token []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if len(token) > 0 && err == io.EOF {
+				return string(token), nil
+			}
+			return "", err
+		}
+		switch {
+		case b == '#':
+			if err := skipComment(r); err != nil && (err != io.EOF || len(token) == 0) {
+				return "", err
+			}
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		case isSpace(b):
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, b)
+		}
+	}
+}
+
+// readPgmHeader reads the magic number, size and maxval of a netpbm file and leaves r at the first pixel.
+func readPgmHeader(r *bufio.Reader, path string) (pgmHeader, error) {
+	header := pgmHeader{maxval: 1}
+	magic, err := readToken(r)
+	if err != nil || (magic != "P1" && magic != "P2" && magic != "P4" && magic != "P5") {
+		return header, fmt.Errorf("%w: %s", ErrNotPGM, path)
+	}
+	header.magic = magic
+
+	fields := []*int{&header.width, &header.height}
+	if magic == "P2" || magic == "P5" {
+		fields = append(fields, &header.maxval)
+	}
+	for _, field := range fields {
+		token, err := readToken(r)
+		if err == nil {
+			*field, err = strconv.Atoi(token)
+		}
+		// a maxval of 0 is reported as a bad bit depth below
+		if err != nil || (*field <= 0 && field != &header.maxval) {
+			return header, fmt.Errorf("%w: %s has a bad header", ErrNotPGM, path)
+		}
+	}
+	if header.maxval < 1 || header.maxval > 65535 {
+		return header, fmt.Errorf("%w: %s has maxval %d", ErrBitDepth, path, header.maxval)
+	}
+	return header, nil
+}
+
+// ReadImageSize returns the width and height in the header of a netpbm file.
+func ReadImageSize(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+	header, err := readPgmHeader(bufio.NewReader(file), path)
+	return header.width, header.height, err
+}
+
+// ReadAliveCells reads a netpbm file, such as one written at the end of a run, and returns its size
+// and alive cells. A pixel is alive if it is above the threshold, as in Params.
+func ReadAliveCells(path string, threshold float64) (int, int, []util.Cell, error) {
+	width, height, err := ReadImageSize(path)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	image, err := readNetpbm(path, width, height, threshold)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var alive []util.Cell
+	for i, pixel := range image {
+		if pixel != 0 {
+			alive = append(alive, util.Cell{X: i % width, Y: i / width})
+		}
+	}
+	return width, height, alive, nil
+}
+
+// readSamples reads every pixel of the image as a value between 0 and maxval.
+func (header pgmHeader) readSamples(r *bufio.Reader) ([]int, error) {
+	samples := make([]int, header.width*header.height)
+	switch header.magic {
+	case "P1":
+		// the bits may or may not be separated by whitespace
+		for i := 0; i < len(samples); {
+			b, err := r.ReadByte()
+			if err != nil {
+				return samples[:i], err
+			}
+			switch {
+			case b == '0' || b == '1':
+				samples[i] = int(b - '0')
+				i++
+			case b == '#':
+				if err := skipComment(r); err != nil {
+					return samples[:i], err
+				}
+			case !isSpace(b):
+				return samples[:i], fmt.Errorf("unexpected %q in pbm data", b)
+			}
+		}
+	case "P2":
+		for i := range samples {
+			token, err := readToken(r)
+			if err != nil {
+				return samples[:i], err
+			}
+			if samples[i], err = strconv.Atoi(token); err != nil {
+				return samples[:i], fmt.Errorf("bad pixel %q in pgm data", token)
+			}
+		}
+	case "P4":
+		// every row starts on a new byte and the first pixel is the most significant bit
+		row := make([]byte, (header.width+7)/8)
+		for y := 0; y < header.height; y++ {
+			if _, err := io.ReadFull(r, row); err != nil {
+				return samples[:y*header.width], err
+			}
+			for x := 0; x < header.width; x++ {
+				samples[y*header.width+x] = int(row[x/8]>>uint(7-x%8)) & 1
+			}
+		}
+	case "P5":
+		// samples above 255 take two bytes, most significant first
+		size := 1
+		if header.maxval > 255 {
+			size = 2
+		}
+		data := make([]byte, len(samples)*size)
+		if n, err := io.ReadFull(r, data); err != nil {
+			return samples[:n/size], err
+		}
+		for i := range samples {
+			if size == 1 {
+				samples[i] = int(data[i])
+			} else {
+				samples[i] = int(data[2*i])<<8 | int(data[2*i+1])
+			}
+		}
+	}
+	return samples, nil
+}
+
+// readNetpbm reads a P1, P2, P4 or P5 file and checks that it holds a width x height image.
+// A pixel is alive if it is above threshold times the maxval, so a threshold of 0 makes every
+// non-zero pixel alive. In the 1-bit formats a 1, which is black, is alive.
+// The image is returned as one byte per pixel, 255 for alive and 0 for dead.
+func readNetpbm(path string, width, height int, threshold float64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	header, err := readPgmHeader(r, path)
+	if err != nil {
+		return nil, err
+	}
+	if header.width != width || header.height != height {
+		return nil, fmt.Errorf("%w: %s is %dx%d, expected %dx%d", ErrDimensionMismatch, path, header.width, header.height, width, height)
+	}
+
+	samples, err := header.readSamples(r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: %s has %d of %d pixels", ErrTruncated, path, len(samples), width*height)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNotPGM, path, err)
+	}
+
+	image := make([]byte, len(samples))
+	for i, sample := range samples {
+		if sample > header.maxval {
+			return nil, fmt.Errorf("%w: %s has pixel %d above maxval %d", ErrBitDepth, path, sample, header.maxval)
+		}
+		if float64(sample) > threshold*float64(header.maxval) {
+			image[i] = 255
+		}
+	}
+	return image, nil
+}
+
+// writePgm writes a P5 file with a maxval of 255.
+func writePgm(path string, width, height int, world []byte) error {
+	header := "P5\n" + strconv.Itoa(width) + " " + strconv.Itoa(height) + "\n" + strconv.Itoa(255) + "\n"
+	return writeImageFile(path, header, world)
+}
+
+// writePbm writes a 1-bit P4 file, where every non-zero pixel of the world is a 1.
+func writePbm(path string, width, height int, world []byte) error {
+	rowBytes := (width + 7) / 8
+	data := make([]byte, rowBytes*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if world[y*width+x] != 0 {
+				data[y*rowBytes+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	header := "P4\n" + strconv.Itoa(width) + " " + strconv.Itoa(height) + "\n"
+	return writeImageFile(path, header, data)
+}
+
+func writeImageFile(path, header string, data []byte) error {
+	_ = os.Mkdir("out", os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(header); err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,198 @@
+package gol
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+var (
+	// ErrBadPattern is returned when a pattern file cannot be parsed.
+	ErrBadPattern = errors.New("bad pattern")
+	// ErrUnknownFormat is returned for a file extension that no format is registered for.
+	ErrUnknownFormat = errors.New("unknown file format")
+)
+
+// pattern is a set of alive cells inside a width x height bounding box.
+type pattern struct {
+	width, height int
+	rule          string
+	alive         [][2]int // x, y
+	// relative is true if the cells are relative to the origin in the params
+	// instead of the top left corner of the bounding box.
+	relative bool
+	// minX and minY are the top left corner of the bounding box of a relative pattern
+	minX, minY int
+}
+
+// patternFormat reads and writes a file format that lists alive cells instead of every pixel.
+type patternFormat struct {
+	// decode reads a pattern, it may stop after the header if headerOnly is true.
+	decode func(r io.Reader, headerOnly bool) (pattern, error)
+	// encode writes the whole world, using the rule and origin in the params.
+	encode func(w io.Writer, world *bitGrid, p Params) error
+}
+
+// patternFormats maps a file extension to its format.
+var patternFormats = map[string]patternFormat{
+	".rle":   {decodeRLE, encodeRLE},
+	".cells": {decodeCells, encodeCells},
+	".lif":   {decodeLife106, encodeLife106},
+	".life":  {decodeLife106, encodeLife106},
+}
+
+func patternFormatOf(path string) (patternFormat, bool) {
+	format, ok := patternFormats[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// IsPattern reports whether a path names a pattern file rather than a pgm image.
+func IsPattern(path string) bool {
+	_, ok := patternFormatOf(path)
+	return ok
+}
+
+// ParseFormats checks a comma separated list of pattern file extensions, such as "rle,cells".
+func ParseFormats(s string) ([]string, error) {
+	var formats []string
+	for _, format := range strings.Split(s, ",") {
+		format = strings.TrimPrefix(strings.TrimSpace(format), ".")
+		if format == "" {
+			continue
+		}
+		if !IsPattern("." + format) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+		}
+		formats = append(formats, strings.ToLower(format))
+	}
+	return formats, nil
+}
+
+// checkBounds makes sure that every alive cell is inside the bounding box from the header.
+func (p pattern) checkBounds() error {
+	for _, cell := range p.alive {
+		if cell[0] >= p.width || cell[1] >= p.height {
+			return fmt.Errorf("%w: cell (%d, %d) is outside the %dx%d pattern", ErrBadPattern, cell[0], cell[1], p.width, p.height)
+		}
+	}
+	return nil
+}
+
+// image places the pattern on a width x height board and returns it as pgm bytes.
+// A pattern with a bounding box goes in the middle of the board, a relative one is placed around the origin.
+func (p pattern) image(width, height int, origin util.Cell) ([]byte, error) {
+	offsetX, offsetY := origin.X, origin.Y
+	if !p.relative {
+		if p.width > width || p.height > height {
+			return nil, fmt.Errorf("%w: the %dx%d pattern does not fit on a %dx%d board", ErrDimensionMismatch, p.width, p.height, width, height)
+		}
+		offsetX, offsetY = (width-p.width)/2, (height-p.height)/2
+	}
+
+	image := make([]byte, width*height)
+	for _, cell := range p.alive {
+		x, y := cell[0]+offsetX, cell[1]+offsetY
+		if x < 0 || x >= width || y < 0 || y >= height {
+			return nil, fmt.Errorf("%w: cell (%d, %d) is outside the %dx%d board", ErrDimensionMismatch, cell[0], cell[1], width, height)
+		}
+		image[y*width+x] = 255
+	}
+	return image, nil
+}
+
+// readPattern reads a pattern file and places it on a width x height board.
+func readPattern(path string, width, height int, origin util.Cell) ([]byte, error) {
+	format, ok := patternFormatOf(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	p, err := format.decode(file, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p.image(width, height, origin)
+}
+
+// ReadPatternHeader returns the size of the bounding box of a pattern file and its rule.
+// The rule is empty if the format or the file does not have one.
+func ReadPatternHeader(path string) (int, int, string, error) {
+	p, err := readPatternHeader(path)
+	return p.width, p.height, p.rule, err
+}
+
+// readPatternHeader reads a pattern file without keeping its cells.
+func readPatternHeader(path string) (pattern, error) {
+	format, ok := patternFormatOf(path)
+	if !ok {
+		return pattern{}, fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return pattern{}, err
+	}
+	defer file.Close()
+	p, err := format.decode(file, true)
+	if err != nil {
+		return pattern{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// writePatternFile writes the world of a checkpoint in the format named by the extension of the path.
+func writePatternFile(path string, cp checkpoint) error {
+	format, ok := patternFormatOf(path)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := format.encode(file, cp.world(), cp.Params); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// WritePattern writes alive cells, such as the ones in FinalTurnComplete, to a file.
+// The format is chosen by the extension of the path and can be pgm, pbm or any pattern format.
+// The size, rule and origin are taken from the params.
+func WritePattern(path string, p Params, alive []util.Cell) error {
+	rule, err := ParseRule(p.Rule)
+	if err != nil {
+		return err
+	}
+	world := newBitGrid(p.ImageHeight, p.ImageWidth)
+	for _, cell := range alive {
+		if cell.X < 0 || cell.X >= p.ImageWidth || cell.Y < 0 || cell.Y >= p.ImageHeight {
+			return fmt.Errorf("%w: cell (%d, %d) is outside the %dx%d board", ErrDimensionMismatch, cell.X, cell.Y, p.ImageWidth, p.ImageHeight)
+		}
+		world.set(cell.Y, cell.X, true)
+	}
+
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension == ".pgm" || extension == ".pbm" {
+		image := make([]byte, p.ImageWidth*p.ImageHeight)
+		for _, cell := range alive {
+			image[cell.Y*p.ImageWidth+cell.X] = 255
+		}
+		if extension == ".pbm" {
+			return writePbm(path, p.ImageWidth, p.ImageHeight, image)
+		}
+		return writePgm(path, p.ImageWidth, p.ImageHeight, image)
+	}
+	return writePatternFile(path, newCheckpoint(p, rule, 0, world))
+}
@@ -0,0 +1,157 @@
+package gol
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Recording describes an animation of the board, written as an animated gif
+// or as a png file for every frame depending on the extension of Path.
+type Recording struct {
+	// Path is where the recording goes, such as out/run.gif. The frames of a png recording
+	// have the turn added before the extension, such as out/run-000100.png.
+	Path string
+	// Every is the number of turns between frames, 0 records every turn.
+	Every int
+	// Scale is the width and height in pixels of a cell, 0 is the same as 1.
+	Scale int
+	// Alive and Dead are the colours of the cells, they default to white and black.
+	Alive, Dead color.RGBA
+	// Delay is the time between frames of a gif in hundredths of a second.
+	Delay int
+	// MaxFrames stops recording after that many frames, 0 records until the end of the run.
+	MaxFrames int
+	// MaxBytes stops a gif once its frames would take up more than that many bytes,
+	// as a gif is kept in memory until the end. 0 is DefaultGIFBytes.
+	MaxBytes int
+}
+
+// DefaultGIFBytes is the most memory the frames of a gif take up if Recording.MaxBytes is 0.
+// A frame takes up a byte for every pixel, so a 512x512 board at scale 4 fits 64 frames.
+const DefaultGIFBytes = 256 << 20
+
+// ParseColour parses a colour written as #rrggbb or rrggbb.
+func ParseColour(s string) (color.RGBA, error) {
+	var c color.RGBA
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return c, fmt.Errorf("bad colour %q, expected #rrggbb", s)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("bad colour %q, expected #rrggbb", s)
+	}
+	c.A = 255
+	return c, nil
+}
+
+// check makes sure that the recording is a gif or png file.
+func (options Recording) check() error {
+	switch strings.ToLower(filepath.Ext(options.Path)) {
+	case ".gif", ".png":
+		return nil
+	}
+	return fmt.Errorf("%w: recordings are .gif or .png, not %s", ErrUnknownFormat, options.Path)
+}
+
+// recorder collects the frames of a recording in the io goroutine.
+type recorder struct {
+	options Recording
+	palette color.Palette
+	frames  int
+	bytes   int
+	gif     *gif.GIF
+}
+
+func newRecorder(options Recording) *recorder {
+	if options.Scale < 1 {
+		options.Scale = 1
+	}
+	if options.Alive.A == 0 {
+		options.Alive = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	if options.Dead.A == 0 {
+		options.Dead = color.RGBA{A: 255}
+	}
+	r := &recorder{options: options, palette: color.Palette{options.Dead, options.Alive}}
+	if r.isGIF() {
+		r.gif = &gif.GIF{}
+		if r.options.MaxBytes < 1 {
+			r.options.MaxBytes = DefaultGIFBytes
+		}
+	}
+	return r
+}
+
+func (r *recorder) isGIF() bool {
+	return strings.EqualFold(filepath.Ext(r.options.Path), ".gif")
+}
+
+// frame draws the world with every cell as a Scale x Scale square.
+func (r *recorder) frame(world *bitGrid) *image.Paletted {
+	scale := r.options.Scale
+	img := image.NewPaletted(image.Rect(0, 0, world.width*scale, world.height*scale), r.palette)
+	for _, cell := range world.aliveCells() {
+		for y := cell.Y * scale; y < (cell.Y+1)*scale; y++ {
+			row := img.Pix[y*img.Stride:]
+			for x := cell.X * scale; x < (cell.X+1)*scale; x++ {
+				row[x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// addFrame records the world at the given turn, unless MaxFrames have been recorded already
+// or a gif has no room left for another frame.
+func (r *recorder) addFrame(world *bitGrid, turn int) error {
+	if r.options.MaxFrames > 0 && r.frames >= r.options.MaxFrames {
+		return nil
+	}
+	if r.gif != nil {
+		size := world.width * world.height * r.options.Scale * r.options.Scale
+		if r.bytes+size > r.options.MaxBytes {
+			return nil
+		}
+		r.bytes += size
+	}
+	r.frames++
+	img := r.frame(world)
+	if r.gif != nil {
+		r.gif.Image = append(r.gif.Image, img)
+		r.gif.Delay = append(r.gif.Delay, r.options.Delay)
+		return nil
+	}
+
+	extension := filepath.Ext(r.options.Path)
+	path := fmt.Sprintf("%s-%06d%s", strings.TrimSuffix(r.options.Path, extension), turn, extension)
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// close writes a gif recording, the frames of a png recording have been written already.
+func (r *recorder) close() error {
+	if r.gif == nil || len(r.gif.Image) == 0 {
+		return nil
+	}
+	_ = os.MkdirAll(filepath.Dir(r.options.Path), os.ModePerm)
+	file, err := os.Create(r.options.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := gif.EncodeAll(file, r.gif); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,127 @@
+package gol
+
+import (
+	"net/rpc"
+
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// NextStrip calculates a strip of the next turn on behalf of a remote worker.
+func NextStrip(req stubs.StripRequest) (stubs.StripResponse, error) {
+	rule, err := ParseRule(req.Rule)
+	if err != nil {
+		return stubs.StripResponse{}, err
+	}
+	topology := Topology(req.Topology)
+
+	s := &strip{
+		startY: req.StartY,
+		endY:   req.EndY,
+		height: req.Height,
+		width:  req.Width,
+		rows:   req.Rows,
+		above:  req.Above,
+		below:  req.Below,
+	}
+	if topology.needsEdgeColumns() {
+		s.edges = [2][]bool{req.Left, req.Right}
+	}
+	next := make([][]uint64, len(req.Rows))
+	for i := range next {
+		next[i] = make([]uint64, wordsFor(req.Width))
+	}
+	nextRows(s, next, req.StartY, req.EndY, req.Height, req.Width, rule, topology)
+
+	result := compareRows(req.Rows, next, req.StartY)
+	return stubs.StripResponse{Rows: next, Flipped: result.flipped, Alive: result.alive}, nil
+}
+
+// brokerStepper runs the turns on a remote broker, which splits them across its workers.
+type brokerStepper struct {
+	client *rpc.Client
+	alive  int
+}
+
+// dialBroker connects to the broker at p.Broker and hands it the world at the given turn.
+func dialBroker(world *bitGrid, p Params, turn int) (*brokerStepper, error) {
+	client, err := rpc.Dial("tcp", p.Broker)
+	if err != nil {
+		return nil, err
+	}
+
+	req := stubs.StartRequest{
+		World:    stubs.World{Width: world.width, Height: world.height, Rows: world.rows},
+		Turn:     turn,
+		Turns:    p.Turns,
+		Rule:     p.Rule,
+		Topology: int(p.Topology),
+	}
+	if err := client.Call(stubs.BrokerStart, req, &stubs.Empty{}); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return &brokerStepper{client: client, alive: world.aliveCellCount()}, nil
+}
+
+// attachBroker connects to the broker at p.Broker and takes over the world it is running,
+// returning the world along with the number of turns completed so far.
+func attachBroker(p Params) (*brokerStepper, *bitGrid, int, error) {
+	client, err := rpc.Dial("tcp", p.Broker)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// the broker checks the size before it hands over the world, so a mismatch leaves its run going
+	var res stubs.WorldResponse
+	req := stubs.AttachRequest{Width: p.ImageWidth, Height: p.ImageHeight}
+	if err := client.Call(stubs.BrokerAttach, req, &res); err != nil {
+		_ = client.Close()
+		return nil, nil, 0, err
+	}
+
+	world := &bitGrid{width: res.World.Width, height: res.World.Height, rows: res.World.Rows}
+	return &brokerStepper{client: client, alive: world.aliveCellCount()}, world, res.Turn, nil
+}
+
+// step asks the broker for a single turn.
+func (b *brokerStepper) step(int) ([]util.Cell, int, error) {
+	var res stubs.StepResponse
+	if err := b.client.Call(stubs.BrokerStep, stubs.Empty{}, &res); err != nil {
+		return nil, 0, err
+	}
+	b.alive = res.Alive
+	return res.Flipped, 1, nil
+}
+
+func (b *brokerStepper) world() (*bitGrid, error) {
+	var res stubs.WorldResponse
+	if err := b.client.Call(stubs.BrokerWorld, stubs.Empty{}, &res); err != nil {
+		return nil, err
+	}
+	return &bitGrid{width: res.World.Width, height: res.World.Height, rows: res.World.Rows}, nil
+}
+
+func (b *brokerStepper) aliveCellCount() int {
+	return b.alive
+}
+
+// stop detaches from the broker, so that another controller can attach to its world, and disconnects.
+// It is used when the run has finished or failed, where there is nothing to do about a broker that cannot be reached.
+func (b *brokerStepper) stop() {
+	_ = b.detach()
+}
+
+// detach disconnects from the broker, which carries on running turns by itself.
+func (b *brokerStepper) detach() error {
+	err := b.client.Call(stubs.BrokerDetach, stubs.Empty{}, &stubs.Empty{})
+	_ = b.client.Close()
+	return err
+}
+
+// shutdown stops the broker and all of its workers.
+func (b *brokerStepper) shutdown() error {
+	err := b.client.Call(stubs.BrokerShutdown, stubs.Empty{}, &stubs.Empty{})
+	_ = b.client.Close()
+	return err
+}
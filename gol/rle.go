@@ -0,0 +1,159 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// rleLineLength is the longest line written in the body of an rle file.
+const rleLineLength = 70
+
+// parseRLEHeader parses a line such as "x = 3, y = 3, rule = B3/S23".
+func parseRLEHeader(line string) (pattern, error) {
+	var p pattern
+	seen := 0
+	for _, field := range strings.Split(line, ",") {
+		keyValue := strings.SplitN(field, "=", 2)
+		if len(keyValue) != 2 {
+			return p, fmt.Errorf("%w: bad rle header %q", ErrBadPattern, line)
+		}
+		key, value := strings.TrimSpace(keyValue[0]), strings.TrimSpace(keyValue[1])
+		var err error
+		switch key {
+		case "x":
+			p.width, err = strconv.Atoi(value)
+			seen++
+		case "y":
+			p.height, err = strconv.Atoi(value)
+			seen++
+		case "rule":
+			p.rule = value
+		}
+		if err != nil || p.width < 0 || p.height < 0 {
+			return p, fmt.Errorf("%w: bad rle header %q", ErrBadPattern, line)
+		}
+	}
+	if seen != 2 {
+		return p, fmt.Errorf("%w: rle header %q needs x and y", ErrBadPattern, line)
+	}
+	return p, nil
+}
+
+// decodeRLE reads an rle pattern. Comment lines starting with '#' are skipped.
+// If headerOnly is true it stops after the header line.
+func decodeRLE(r io.Reader, headerOnly bool) (pattern, error) {
+	var p pattern
+	scanner := bufio.NewScanner(r)
+	header := false
+	x, y, count := 0, 0, 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if !header {
+			var err error
+			if p, err = parseRLEHeader(line); err != nil || headerOnly {
+				return p, err
+			}
+			header = true
+			continue
+		}
+
+		for _, b := range line {
+			if b >= '0' && b <= '9' {
+				count = count*10 + int(b-'0')
+				continue
+			}
+			if count == 0 {
+				count = 1
+			}
+			switch b {
+			case 'b':
+				x += count
+			case 'o':
+				for i := 0; i < count; i++ {
+					p.alive = append(p.alive, [2]int{x + i, y})
+				}
+				x += count
+			case '$':
+				y += count
+				x = 0
+			case '!':
+				return p, p.checkBounds()
+			default:
+				return p, fmt.Errorf("%w: unexpected %q in rle data", ErrBadPattern, b)
+			}
+			count = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return p, err
+	}
+	if !header {
+		return p, fmt.Errorf("%w: missing rle header", ErrBadPattern)
+	}
+	return p, fmt.Errorf("%w: rle data does not end with '!'", ErrBadPattern)
+}
+
+// rleWriter writes runs of an rle body, wrapping lines at rleLineLength.
+type rleWriter struct {
+	w    *bufio.Writer
+	line int
+}
+
+func (rw *rleWriter) run(count int, tag byte) {
+	if count == 0 {
+		return
+	}
+	s := string(tag)
+	if count > 1 {
+		s = strconv.Itoa(count) + s
+	}
+	if rw.line+len(s) > rleLineLength {
+		_ = rw.w.WriteByte('\n')
+		rw.line = 0
+	}
+	_, _ = rw.w.WriteString(s)
+	rw.line += len(s)
+}
+
+// encodeRLE writes the whole world as an rle pattern. Dead cells at the end of a row
+// and empty rows at the end of the world are left out.
+func encodeRLE(w io.Writer, world *bitGrid, p Params) error {
+	buffered := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(buffered, "x = %d, y = %d, rule = %s\n", world.width, world.height, p.Rule)
+
+	rw := &rleWriter{w: buffered}
+	previous := 0
+	for y := 0; y < world.height; y++ {
+		// count the alive cells so that the run of dead cells at the end can be left out
+		alive := countSetBits(world.row(y))
+		if alive == 0 {
+			continue
+		}
+		rw.run(y-previous, '$')
+		previous = y
+
+		x := 0
+		for alive > 0 {
+			start := x
+			for !world.get(y, x) {
+				x++
+			}
+			rw.run(x-start, 'b')
+			start = x
+			for x < world.width && world.get(y, x) {
+				x++
+			}
+			rw.run(x-start, 'o')
+			alive -= x - start
+		}
+	}
+	rw.run(1, '!')
+	_ = buffered.WriteByte('\n')
+	return buffered.Flush()
+}
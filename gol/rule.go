@@ -0,0 +1,93 @@
+package gol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is an outer-totalistic rule, e.g. B3/S23 for Conway's Game of Life.
+// Birth[n] is true if a dead cell with n alive neighbours becomes alive,
+// Survive[n] is true if an alive cell with n alive neighbours stays alive.
+type Rule struct {
+	Birth   [9]bool
+	Survive [9]bool
+}
+
+// ConwayRule is the rulestring used when Params.Rule is left empty.
+const ConwayRule = "B3/S23"
+
+// ParseRule parses a rulestring in B/S notation such as "B36/S23".
+// The two halves may come in either order and letters are case-insensitive.
+// The older S/B notation without letters ("23/36") is also accepted.
+// An empty string is parsed as ConwayRule.
+func ParseRule(s string) (Rule, error) {
+	var rule Rule
+	s = strings.TrimSpace(s)
+	if s == "" {
+		s = ConwayRule
+	}
+
+	parts := strings.Split(strings.ToUpper(s), "/")
+	if len(parts) != 2 {
+		return rule, fmt.Errorf("rule %q: expected two parts separated by '/'", s)
+	}
+
+	// S/B notation: survival digits first, birth digits second
+	if !strings.ContainsAny(parts[0]+parts[1], "BS") {
+		parts[0], parts[1] = "S"+parts[0], "B"+parts[1]
+	}
+
+	seen := make(map[byte]bool)
+	for _, part := range parts {
+		if part == "" {
+			return rule, fmt.Errorf("rule %q: empty part", s)
+		}
+		var counts *[9]bool
+		switch part[0] {
+		case 'B':
+			counts = &rule.Birth
+		case 'S':
+			counts = &rule.Survive
+		default:
+			return rule, fmt.Errorf("rule %q: part %q must start with B or S", s, part)
+		}
+		if seen[part[0]] {
+			return rule, fmt.Errorf("rule %q: %c given twice", s, part[0])
+		}
+		seen[part[0]] = true
+
+		for _, digit := range part[1:] {
+			if digit < '0' || digit > '8' {
+				return rule, fmt.Errorf("rule %q: invalid neighbour count %q", s, digit)
+			}
+			if counts[digit-'0'] {
+				return rule, fmt.Errorf("rule %q: neighbour count %c given twice", s, digit)
+			}
+			counts[digit-'0'] = true
+		}
+	}
+	return rule, nil
+}
+
+// next returns whether a cell is alive in the next turn, given its current state
+// and its number of alive neighbours.
+func (rule Rule) next(alive bool, neighbours int) bool {
+	if alive {
+		return rule.Survive[neighbours]
+	}
+	return rule.Birth[neighbours]
+}
+
+// String returns the rule in canonical B/S notation.
+func (rule Rule) String() string {
+	var b, s strings.Builder
+	for n := 0; n <= 8; n++ {
+		if rule.Birth[n] {
+			b.WriteByte(byte('0' + n))
+		}
+		if rule.Survive[n] {
+			s.WriteByte(byte('0' + n))
+		}
+	}
+	return "B" + b.String() + "/S" + s.String()
+}
@@ -0,0 +1,41 @@
+package gol
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Soup describes a random board where every cell is alive with probability Density.
+// The same Seed always gives the same board.
+type Soup struct {
+	Density float64
+	Seed    int64
+}
+
+// ParseSoup parses a soup written as density,seed such as "0.35,42".
+func ParseSoup(s string) (Soup, error) {
+	var soup Soup
+	if _, err := fmt.Sscanf(s, "%g,%d", &soup.Density, &soup.Seed); err != nil {
+		return soup, fmt.Errorf("bad soup %q, expected density,seed", s)
+	}
+	if soup.Density <= 0 || soup.Density > 1 {
+		return soup, fmt.Errorf("bad soup %q, the density has to be above 0 and at most 1", s)
+	}
+	return soup, nil
+}
+
+func (soup Soup) String() string {
+	return fmt.Sprintf("soup %v,%v", soup.Density, soup.Seed)
+}
+
+// image fills a width x height board at random and returns it as pgm bytes, a row at a time from the top.
+func (soup Soup) image(width, height int) []byte {
+	random := rand.New(rand.NewSource(soup.Seed))
+	image := make([]byte, width*height)
+	for i := range image {
+		if random.Float64() < soup.Density {
+			image[i] = 255
+		}
+	}
+	return image
+}
@@ -0,0 +1,75 @@
+package gol
+
+import (
+	"fmt"
+	"time"
+)
+
+// the rates that '+' and '-' move between, going faster than maxTurnsPerSecond removes the limit
+const (
+	minTurnsPerSecond = 0.25
+	maxTurnsPerSecond = 1024
+)
+
+// speed limits the number of turns run per second, a rate of 0 runs them as fast as possible.
+type speed struct {
+	rate float64
+	// next is when the next turn is due
+	next time.Time
+}
+
+func (s *speed) String() string {
+	if s.rate <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g turns per second", s.rate)
+}
+
+// faster doubles the rate, removing the limit once it passes maxTurnsPerSecond.
+func (s *speed) faster() {
+	if s.rate <= 0 {
+		return
+	}
+	s.rate *= 2
+	if s.rate > maxTurnsPerSecond {
+		s.rate = 0
+	}
+}
+
+// slower halves the rate, an unlimited speed drops to maxTurnsPerSecond.
+func (s *speed) slower() {
+	if s.rate <= 0 {
+		s.rate = maxTurnsPerSecond
+		return
+	}
+	if s.rate/2 >= minTurnsPerSecond {
+		s.rate /= 2
+	}
+}
+
+// wait returns how long until the next turn is due, which is 0 if it can run now.
+func (s *speed) wait() time.Duration {
+	if s.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	// after a pause, or after the rate has gone down, start again from now instead of catching up
+	if s.next.IsZero() || now.Sub(s.next) > time.Second || s.next.Sub(now) > s.interval() {
+		s.next = now
+	}
+	if wait := s.next.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// stepped moves the next turn on by the number of turns that have just run.
+func (s *speed) stepped(turns int) {
+	if s.rate > 0 {
+		s.next = s.next.Add(time.Duration(turns) * s.interval())
+	}
+}
+
+func (s *speed) interval() time.Duration {
+	return time.Duration(float64(time.Second) / s.rate)
+}
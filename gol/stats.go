@@ -0,0 +1,197 @@
+package gol
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// statsBatch is how many rows of statistics are sent to the io goroutine at once.
+const statsBatch = 1000
+
+// turnStats are the statistics of the world after a turn.
+type turnStats struct {
+	turn           int
+	alive          int
+	births, deaths int
+	// the bounding box of the alive cells, which is only set if there are any
+	minX, minY, maxX, maxY int
+	density                float64
+}
+
+// statsCollector keeps its own copy of the world up to date with the flipped cells of every turn,
+// so that births and deaths can be told apart, along with the number of alive cells in every row
+// and column so that the bounding box can be found without scanning the board.
+type statsCollector struct {
+	world                  *bitGrid
+	alive                  int
+	rowCounts, colCounts   []int
+	minX, minY, maxX, maxY int
+	// rows are the statistics that have not been written yet.
+	// The last keep of them are held back so that they can still be rewound.
+	rows []turnStats
+	keep int
+}
+
+func newStatsCollector(world *bitGrid, keep int) *statsCollector {
+	s := &statsCollector{keep: keep}
+	s.reset(world)
+	return s
+}
+
+// reset counts the alive cells of a copy of the world.
+func (s *statsCollector) reset(world *bitGrid) {
+	s.world = world.clone()
+	s.alive = 0
+	s.rowCounts = make([]int, world.height)
+	s.colCounts = make([]int, world.width)
+	for _, cell := range s.world.aliveCells() {
+		s.count(cell, 1)
+	}
+	s.minX, s.minY, s.maxX, s.maxY = 0, 0, world.width-1, world.height-1
+	s.shrink()
+}
+
+// count adds delta to the counts of the row and column of a cell.
+func (s *statsCollector) count(cell util.Cell, delta int) {
+	s.alive += delta
+	s.rowCounts[cell.Y] += delta
+	s.colCounts[cell.X] += delta
+}
+
+// shrink moves the edges of the bounding box in until each one has an alive cell on it.
+func (s *statsCollector) shrink() {
+	if s.alive == 0 {
+		return
+	}
+	for s.rowCounts[s.minY] == 0 {
+		s.minY++
+	}
+	for s.rowCounts[s.maxY] == 0 {
+		s.maxY--
+	}
+	for s.colCounts[s.minX] == 0 {
+		s.minX++
+	}
+	for s.colCounts[s.maxX] == 0 {
+		s.maxX--
+	}
+}
+
+// add records the turn reached by flipping the given cells.
+func (s *statsCollector) add(turn int, flipped []util.Cell) {
+	row := turnStats{turn: turn}
+	for _, cell := range flipped {
+		alive := !s.world.get(cell.Y, cell.X)
+		s.world.set(cell.Y, cell.X, alive)
+		if alive {
+			row.births++
+			s.count(cell, 1)
+			// births can only grow the box, deaths are dealt with by shrinking it afterwards
+			if cell.X < s.minX {
+				s.minX = cell.X
+			}
+			if cell.X > s.maxX {
+				s.maxX = cell.X
+			}
+			if cell.Y < s.minY {
+				s.minY = cell.Y
+			}
+			if cell.Y > s.maxY {
+				s.maxY = cell.Y
+			}
+		} else {
+			row.deaths++
+			s.count(cell, -1)
+		}
+	}
+	s.shrink()
+	row.alive = s.alive
+	row.minX, row.minY, row.maxX, row.maxY = s.minX, s.minY, s.maxX, s.maxY
+	row.density = float64(row.alive) / float64(s.world.width*s.world.height)
+	s.rows = append(s.rows, row)
+}
+
+// ready returns a batch of rows to be written once enough have built up, leaving the last keep of them.
+func (s *statsCollector) ready() []turnStats {
+	if len(s.rows) < s.keep+statsBatch {
+		return nil
+	}
+	n := len(s.rows) - s.keep
+	batch := append([]turnStats(nil), s.rows[:n]...)
+	s.rows = append(s.rows[:0], s.rows[n:]...)
+	return batch
+}
+
+// rest returns every row that has not been written yet.
+func (s *statsCollector) rest() []turnStats {
+	rows := s.rows
+	s.rows = nil
+	return rows
+}
+
+// rewind starts again from the world at the given turn, forgetting any rows after it.
+// This is needed after the world has been edited or stepped while paused.
+func (s *statsCollector) rewind(world *bitGrid, turn int) {
+	s.reset(world)
+	for len(s.rows) > 0 && s.rows[len(s.rows)-1].turn > turn {
+		s.rows = s.rows[:len(s.rows)-1]
+	}
+}
+
+// statsWriter appends rows of statistics to a csv file as they arrive.
+type statsWriter struct {
+	file *os.File
+	out  *csv.Writer
+}
+
+// newStatsWriter creates the csv file and writes its header, starting with the same two columns as check/alive.
+func newStatsWriter(path string) (*statsWriter, error) {
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &statsWriter{file: file, out: csv.NewWriter(file)}
+	_ = w.out.Write([]string{"completed_turns", "alive_cells", "births", "deaths", "min_x", "min_y", "max_x", "max_y", "density"})
+	return w, nil
+}
+
+// write appends a row for every turn.
+func (w *statsWriter) write(rows []turnStats) error {
+	for _, row := range rows {
+		record := []string{
+			strconv.Itoa(row.turn),
+			strconv.Itoa(row.alive),
+			strconv.Itoa(row.births),
+			strconv.Itoa(row.deaths),
+			"", "", "", "",
+			strconv.FormatFloat(row.density, 'g', -1, 64),
+		}
+		// an empty world has no bounding box
+		if row.alive > 0 {
+			record[4], record[5] = strconv.Itoa(row.minX), strconv.Itoa(row.minY)
+			record[6], record[7] = strconv.Itoa(row.maxX), strconv.Itoa(row.maxY)
+		}
+		_ = w.out.Write(record)
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
+
+// close syncs and closes the file.
+func (w *statsWriter) close() error {
+	w.out.Flush()
+	if err := w.out.Error(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,121 @@
+package gol
+
+import "fmt"
+
+// Topology describes how the edges of the board are joined together.
+type Topology int
+
+const (
+	// Torus joins the left edge to the right edge and the top edge to the bottom edge.
+	Torus Topology = iota
+	// Plane is a bounded board, every cell outside it is dead.
+	Plane
+	// KleinBottle is a torus where crossing the top or bottom edge mirrors the column.
+	KleinBottle
+	// Cylinder joins the left edge to the right edge, the top and bottom edges are bounded.
+	Cylinder
+	// ProjectivePlane mirrors the column when crossing the top or bottom edge
+	// and mirrors the row when crossing the left or right edge.
+	ProjectivePlane
+)
+
+var topologyNames = map[Topology]string{
+	Torus:           "torus",
+	Plane:           "plane",
+	KleinBottle:     "klein",
+	Cylinder:        "cylinder",
+	ProjectivePlane: "projective",
+}
+
+// ParseTopology returns the Topology with the given name, as printed by Topology.String.
+func ParseTopology(s string) (Topology, error) {
+	for topology, name := range topologyNames {
+		if name == s {
+			return topology, nil
+		}
+	}
+	return Torus, fmt.Errorf("unknown topology %q", s)
+}
+
+func (topology Topology) String() string {
+	if name, ok := topologyNames[topology]; ok {
+		return name
+	}
+	return "Incorrect Topology"
+}
+
+// needsEdgeColumns returns whether a neighbour beyond the left or right edge
+// can be mapped onto a different row, so workers need the edge columns of the whole world.
+func (topology Topology) needsEdgeColumns() bool {
+	return topology == ProjectivePlane
+}
+
+// neighbour maps the coordinates of a neighbour, which may lie just outside the board,
+// back onto the board. It returns false if the neighbour does not exist and counts as dead.
+func (topology Topology) neighbour(row, col, height, width int) (int, int, bool) {
+	rowOut := row < 0 || row >= height
+	colOut := col < 0 || col >= width
+
+	switch topology {
+	case Plane:
+		return row, col, !rowOut && !colOut
+	case Cylinder:
+		return row, (col + width) % width, !rowOut
+	case KleinBottle:
+		if rowOut {
+			row = (row + height) % height
+			col = width - 1 - col
+		}
+		return row, (col + width) % width, true
+	case ProjectivePlane:
+		if rowOut {
+			row = (row + height) % height
+			col = width - 1 - col
+		}
+		if col < 0 || col >= width {
+			col = (col + width) % width
+			row = height - 1 - row
+		}
+		return row, col, true
+	default:
+		return (row + height) % height, (col + width) % width, true
+	}
+}
+
+// Wrap returns a function that maps a position, however far off the board, back onto the board
+// by crossing the edges the way the topology joins them. It returns false for a position past a bounded edge.
+// Neighbours just outside the board are mapped the same way as neighbour maps them.
+func (topology Topology) Wrap(width, height int) func(x, y int) (int, int, bool) {
+	return func(x, y int) (int, int, bool) {
+		rowOut := y < 0 || y >= height
+		colOut := x < 0 || x >= width
+		switch topology {
+		case Plane:
+			return x, y, !rowOut && !colOut
+		case Cylinder:
+			return mod(x, width), y, !rowOut
+		case KleinBottle, ProjectivePlane:
+			// every crossing of the top or bottom edge mirrors the column
+			if floorDiv(y, height)%2 != 0 {
+				x = width - 1 - x
+			}
+			y = mod(y, height)
+			if topology == ProjectivePlane && floorDiv(x, width)%2 != 0 {
+				y = height - 1 - y
+			}
+			return mod(x, width), y, true
+		default:
+			return mod(x, width), mod(y, height), true
+		}
+	}
+}
+
+// mod returns a modulo n in the range 0 to n-1, even for negative a.
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+// floorDiv returns a divided by n rounded down, so that a = floorDiv(a, n)*n + mod(a, n).
+func floorDiv(a, n int) int {
+	return (a - mod(a, n)) / n
+}
@@ -0,0 +1,269 @@
+package gol
+
+import (
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// workerCommand allows requesting behaviour from a worker goroutine.
+type workerCommand uint8
+
+const (
+	workerStep workerCommand = iota
+	workerCollect
+	workerStop
+)
+
+// stepResult is sent back by every worker after it has calculated a turn.
+type stepResult struct {
+	flipped []util.Cell
+	alive   int
+}
+
+// edgeColumns holds the first and last cell of every row of a strip.
+// They are only exchanged for topologies that join rows to columns.
+type edgeColumns struct {
+	startY      int
+	left, right []bool
+}
+
+type workerChannels struct {
+	command <-chan workerCommand
+	result  chan<- stepResult
+	rows    chan<- [][]uint64
+
+	// haloAbove receives the row above the strip, haloBelow the row below it.
+	haloAbove <-chan []uint64
+	haloBelow <-chan []uint64
+	// toAbove sends the top row to the worker above, toBelow the bottom row to the worker below.
+	toAbove chan<- []uint64
+	toBelow chan<- []uint64
+
+	edges   <-chan edgeColumns
+	toEdges []chan<- edgeColumns
+}
+
+// strip is the part of the world that a single worker owns for the whole run.
+type strip struct {
+	startY, endY  int
+	height, width int
+	rows, next    [][]uint64
+	above, below  []uint64
+	edges         [2][]bool
+}
+
+// neighbourhoodRow returns row y if it is owned by the strip or is one of its halo rows, otherwise nil.
+func (s *strip) neighbourhoodRow(y int) []uint64 {
+	switch {
+	case y >= s.startY && y < s.endY:
+		return s.rows[y-s.startY]
+	case y == (s.startY-1+s.height)%s.height:
+		return s.above
+	case y == s.endY%s.height:
+		return s.below
+	}
+	return nil
+}
+
+func (s *strip) row(y int) []uint64 {
+	row := s.neighbourhoodRow(y)
+	if row == nil {
+		panic(fmt.Sprintf("row %d is outside the neighbourhood of the strip [%d, %d)", y, s.startY, s.endY))
+	}
+	return row
+}
+
+func (s *strip) get(row, col int) bool {
+	if r := s.neighbourhoodRow(row); r != nil {
+		return r[col/64]&(1<<uint(col%64)) != 0
+	}
+	if s.edges[0] != nil && col == 0 {
+		return s.edges[0][row]
+	}
+	if s.edges[1] != nil && col == s.width-1 {
+		return s.edges[1][row]
+	}
+	panic(fmt.Sprintf("cell (%d, %d) is outside the neighbourhood of the strip [%d, %d)", col, row, s.startY, s.endY))
+}
+
+// exchangeHalos swaps the top and bottom rows with the neighbouring workers,
+// and the edge columns with every worker if the topology needs them.
+func (s *strip) exchangeHalos(c workerChannels, topology Topology) {
+	c.toAbove <- s.rows[0]
+	c.toBelow <- s.rows[len(s.rows)-1]
+	s.above = <-c.haloAbove
+	s.below = <-c.haloBelow
+
+	if !topology.needsEdgeColumns() {
+		return
+	}
+	edges := edgeColumns{startY: s.startY}
+	for _, row := range s.rows {
+		edges.left = append(edges.left, row[0]&1 != 0)
+		edges.right = append(edges.right, row[(s.width-1)/64]&(1<<uint((s.width-1)%64)) != 0)
+	}
+	for _, out := range c.toEdges {
+		out <- edges
+	}
+	for range c.toEdges {
+		received := <-c.edges
+		copy(s.edges[0][received.startY:], received.left)
+		copy(s.edges[1][received.startY:], received.right)
+	}
+}
+
+// compareRows returns the cells flipped between two versions of the rows starting at startY
+// and the number of alive cells in the newer version.
+func compareRows(rows, next [][]uint64, startY int) stepResult {
+	var result stepResult
+	for i := range rows {
+		diff := make([]uint64, len(rows[i]))
+		for j := range diff {
+			diff[j] = rows[i][j] ^ next[i][j]
+		}
+		result.flipped = appendSetBits(result.flipped, diff, startY+i)
+		result.alive += countSetBits(next[i])
+	}
+	return result
+}
+
+// worker owns a strip of the world for the whole run and calculates its next turn on every workerStep.
+func worker(s *strip, p Params, rule Rule, c workerChannels) {
+	for {
+		switch <-c.command {
+		case workerStep:
+			s.exchangeHalos(c, p.Topology)
+			nextRows(s, s.next, s.startY, s.endY, s.height, s.width, rule, p.Topology)
+
+			result := compareRows(s.rows, s.next, s.startY)
+			s.rows, s.next = s.next, s.rows
+			c.result <- result
+		case workerCollect:
+			c.rows <- s.rows
+		case workerStop:
+			return
+		}
+	}
+}
+
+// workerPool is a set of long-lived workers that each own a horizontal strip of the world.
+type workerPool struct {
+	height, width int
+	commands      []chan workerCommand
+	results       []chan stepResult
+	rows          []chan [][]uint64
+	alive         int
+}
+
+// startWorkers splits the world into one strip per thread and starts a worker for each of them.
+func startWorkers(world *bitGrid, p Params, rule Rule) *workerPool {
+	threads := p.Threads
+	if threads > world.height {
+		threads = world.height
+	}
+	if threads < 1 {
+		threads = 1
+	}
+
+	pool := &workerPool{
+		height:   world.height,
+		width:    world.width,
+		commands: make([]chan workerCommand, threads),
+		results:  make([]chan stepResult, threads),
+		rows:     make([]chan [][]uint64, threads),
+		alive:    world.aliveCellCount(),
+	}
+
+	haloAbove := make([]chan []uint64, threads)
+	haloBelow := make([]chan []uint64, threads)
+	edges := make([]chan edgeColumns, threads)
+	toEdges := make([]chan<- edgeColumns, threads)
+	for i := 0; i < threads; i++ {
+		pool.commands[i] = make(chan workerCommand)
+		pool.results[i] = make(chan stepResult)
+		pool.rows[i] = make(chan [][]uint64)
+		haloAbove[i] = make(chan []uint64, 1)
+		haloBelow[i] = make(chan []uint64, 1)
+		edges[i] = make(chan edgeColumns, threads)
+		toEdges[i] = edges[i]
+	}
+
+	// the first strips are one row taller if the height does not divide evenly
+	smallHeight := world.height / threads
+	startY := 0
+	for i := 0; i < threads; i++ {
+		endY := startY + smallHeight
+		if i < world.height%threads {
+			endY++
+		}
+
+		s := &strip{
+			startY: startY,
+			endY:   endY,
+			height: world.height,
+			width:  world.width,
+			rows:   make([][]uint64, endY-startY),
+			next:   make([][]uint64, endY-startY),
+		}
+		for y := startY; y < endY; y++ {
+			s.rows[y-startY] = append([]uint64(nil), world.rows[y]...)
+			s.next[y-startY] = make([]uint64, len(world.rows[y]))
+		}
+		if p.Topology.needsEdgeColumns() {
+			s.edges = [2][]bool{make([]bool, world.height), make([]bool, world.height)}
+		}
+
+		above, below := (i-1+threads)%threads, (i+1)%threads
+		go worker(s, p, rule, workerChannels{
+			command:   pool.commands[i],
+			result:    pool.results[i],
+			rows:      pool.rows[i],
+			haloAbove: haloAbove[i],
+			haloBelow: haloBelow[i],
+			toAbove:   haloBelow[above],
+			toBelow:   haloAbove[below],
+			edges:     edges[i],
+			toEdges:   toEdges,
+		})
+		startY = endY
+	}
+	return pool
+}
+
+// step calculates a single turn and returns the flipped cells in row-major order.
+func (pool *workerPool) step(int) ([]util.Cell, int, error) {
+	for _, command := range pool.commands {
+		command <- workerStep
+	}
+	var flipped []util.Cell
+	pool.alive = 0
+	for _, result := range pool.results {
+		r := <-result
+		flipped = append(flipped, r.flipped...)
+		pool.alive += r.alive
+	}
+	return flipped, 1, nil
+}
+
+// world collects a copy of every strip.
+func (pool *workerPool) world() (*bitGrid, error) {
+	world := &bitGrid{width: pool.width, height: pool.height}
+	for i, command := range pool.commands {
+		command <- workerCollect
+		for _, row := range <-pool.rows[i] {
+			world.rows = append(world.rows, append([]uint64(nil), row...))
+		}
+	}
+	return world, nil
+}
+
+func (pool *workerPool) aliveCellCount() int {
+	return pool.alive
+}
+
+func (pool *workerPool) stop() {
+	for _, command := range pool.commands {
+		command <- workerStop
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestHashLife tests 16x16, 64x64 and 512x512 images on 0, 1 and 100 turns using the HashLife engine,
+// both one turn at a time and with jumps of up to 64 turns.
+func TestHashLife(t *testing.T) {
+	tests := []gol.Params{
+		{ImageWidth: 16, ImageHeight: 16},
+		{ImageWidth: 64, ImageHeight: 64},
+		{ImageWidth: 512, ImageHeight: 512},
+	}
+	for _, p := range tests {
+		p.Engine = gol.HashLife
+		p.Threads = 1
+		for _, turns := range []int{0, 1, 100} {
+			p.Turns = turns
+			expectedAlive := readAliveCells(
+				"check/images/"+fmt.Sprintf("%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, turns),
+				p.ImageWidth,
+				p.ImageHeight,
+			)
+			for _, step := range []int{0, 6} {
+				p.HashLifeStep = step
+				testName := fmt.Sprintf("%dx%dx%d-step%d", p.ImageWidth, p.ImageHeight, p.Turns, p.HashLifeStep)
+				t.Run(testName, func(t *testing.T) {
+					events := make(chan gol.Event)
+					go gol.Run(p, events, nil)
+					var cells []util.Cell
+					for event := range events {
+						switch e := event.(type) {
+						case gol.FinalTurnComplete:
+							cells = e.Alive
+						}
+					}
+					assertEqualBoard(t, cells, expectedAlive, p)
+				})
+			}
+		}
+	}
+}
+
+// TestHashLifeJump tests that a 512x512 image jumps 10000 turns in a handful of TurnComplete events
+// and that the cells flipped along the way add up to the expected number of alive cells.
+func TestHashLifeJump(t *testing.T) {
+	p := gol.Params{
+		Turns:        10000,
+		Threads:      1,
+		ImageWidth:   512,
+		ImageHeight:  512,
+		Engine:       gol.HashLife,
+		HashLifeStep: 12,
+	}
+	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+
+	board := make(map[util.Cell]bool)
+	jumps := 0
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.TurnComplete:
+			jumps++
+			count := 0
+			for _, isAlive := range board {
+				if isAlive {
+					count++
+				}
+			}
+			if expected, ok := alive[e.CompletedTurns]; ok && count != expected {
+				t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, expected, count)
+			}
+		case gol.FinalTurnComplete:
+			if e.CompletedTurns != p.Turns {
+				t.Fatalf("Incorrect final turn number. Was %d, should be %d.", e.CompletedTurns, p.Turns)
+			}
+			if len(e.Alive) != alive[p.Turns] {
+				t.Fatalf("At turn %v expected %v alive cells, got %v instead", p.Turns, alive[p.Turns], len(e.Alive))
+			}
+		}
+	}
+	// 10000 = 4096 + 4096 + 1024 + 512 + 256 + 16
+	if jumps != 6 {
+		t.Fatalf("Expected 6 TurnComplete events, got %v instead", jumps)
+	}
+}
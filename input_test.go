@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestInput tests that a non-square image with comments in its header is loaded from any path,
+// that its size replaces the one in the params and that the output image is named after it.
+func TestInput(t *testing.T) {
+	for threads := 1; threads <= 16; threads *= 2 {
+		p := gol.Params{
+			Turns:       100,
+			Threads:     threads,
+			ImageWidth:  64,
+			ImageHeight: 64,
+			Input:       "check/in/soup.pgm",
+		}
+		t.Run(fmt.Sprintf("48x30x100-%d", threads), func(t *testing.T) {
+			_ = os.Remove("out/48x30x100.pgm")
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			var cells []util.Cell
+			for event := range events {
+				switch e := event.(type) {
+				case gol.CellFlipped:
+					if e.Cell.X >= 48 || e.Cell.Y >= 30 {
+						t.Fatalf("CellFlipped event for %v outside of the 48x30 image", e.Cell)
+					}
+				case gol.ErrorOccurred:
+					t.Fatal(e.Err)
+				case gol.FinalTurnComplete:
+					cells = e.Alive
+				}
+			}
+			p.ImageWidth, p.ImageHeight = 48, 30
+			assertEqualBoard(t, cells, readAliveCells("check/in/48x30x100.pgm", 48, 30), p)
+			assertEqualBoard(t, readAliveCells("out/48x30x100.pgm", 48, 30), cells, p)
+		})
+	}
+}
+
+// TestImageSize tests that the size is read from a header with comments.
+func TestImageSize(t *testing.T) {
+	width, height, err := gol.ReadImageSize("check/in/soup.pgm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 48 || height != 30 {
+		t.Fatalf("Expected 48x30, got %dx%d", width, height)
+	}
+}
+
+// TestInputCorrupt tests that a corrupted image given as the input is reported as an error.
+func TestInputCorrupt(t *testing.T) {
+	tests := map[string]error{
+		"check/corrupt/9x9-notpgm.pgm":      gol.ErrNotPGM,
+		"check/corrupt/11x11-maxval.pgm":    gol.ErrBitDepth,
+		"check/corrupt/12x12-truncated.pgm": gol.ErrTruncated,
+		"check/corrupt/14x14-header.pgm":    gol.ErrNotPGM,
+	}
+	for path, expected := range tests {
+		t.Run(path, func(t *testing.T) {
+			p := gol.Params{Turns: 10, Threads: 2, Input: path}
+			if err := runUntilError(t, p); !errors.Is(err, expected) {
+				t.Fatalf("Expected %v, got %v", expected, err)
+			}
+		})
+	}
+}
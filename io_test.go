@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// runUntilError runs the params and returns the error from the ErrorOccurred event.
+// It fails the test if the run does not stop with a single ErrorOccurred followed by Quitting.
+func runUntilError(t *testing.T, p gol.Params) error {
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var err error
+	var last gol.Event
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			if err != nil {
+				t.Fatalf("More than one ErrorOccurred event: %v and %v", err, e.Err)
+			}
+			err = e.Err
+		case gol.FinalTurnComplete:
+			t.Fatal("FinalTurnComplete was sent after an error")
+		}
+		last = event
+	}
+	if err == nil {
+		t.Fatal("No ErrorOccurred event was sent")
+	}
+	if state, ok := last.(gol.StateChange); !ok || state.NewState != gol.Quitting {
+		t.Fatalf("The last event was %#v, expected a Quitting StateChange", last)
+	}
+	return err
+}
+
+// TestCorruptImage tests that every corrupted pgm in check/corrupt is reported with the right error
+// instead of a panic. The fixtures are copied into images under sizes that no real image uses.
+func TestCorruptImage(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		size     int
+		expected error
+	}{
+		{"9x9-notpgm", 9, gol.ErrNotPGM},
+		{"10x10-size", 10, gol.ErrDimensionMismatch},
+		{"11x11-maxval", 11, gol.ErrBitDepth},
+		{"12x12-truncated", 12, gol.ErrTruncated},
+		{"14x14-header", 14, gol.ErrNotPGM},
+		{"15x15-maxval0", 15, gol.ErrBitDepth},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			data, err := ioutil.ReadFile("check/corrupt/" + test.fixture + ".pgm")
+			util.Check(err)
+			// the run reads images/ of a temporary directory, so that the tracked images are left alone
+			dir := t.TempDir()
+			util.Check(os.Mkdir(filepath.Join(dir, "images"), 0755))
+			path := filepath.Join(dir, "images", fmt.Sprintf("%dx%d.pgm", test.size, test.size))
+			util.Check(ioutil.WriteFile(path, data, 0644))
+			t.Chdir(dir)
+
+			p := gol.Params{Turns: 10, Threads: 2, ImageWidth: test.size, ImageHeight: test.size}
+			if err := runUntilError(t, p); !errors.Is(err, test.expected) {
+				t.Fatalf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// TestMissingImage tests that an image that does not exist is reported as an error.
+func TestMissingImage(t *testing.T) {
+	p := gol.Params{Turns: 10, Threads: 2, ImageWidth: 13, ImageHeight: 13}
+	if err := runUntilError(t, p); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+// TestCorruptCheckpoint tests that resuming from a file that is not a checkpoint is reported as an error.
+func TestCorruptCheckpoint(t *testing.T) {
+	p := gol.Params{Turns: 10, Threads: 2, Resume: "check/corrupt/12x12-truncated.pgm"}
+	if err := runUntilError(t, p); !errors.Is(err, gol.ErrBadCheckpoint) {
+		t.Fatalf("Expected %v, got %v", gol.ErrBadCheckpoint, err)
+	}
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
 
+	"uk.ac.bris.cs/gameoflife/census"
 	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/tui"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 // main is the function called when starting Game of Life with 'go run .'
@@ -38,6 +42,202 @@ func main() {
 		10000000000,
 		"Specify the number of turns to process. Defaults to 10000000000.")
 
+	flag.StringVar(
+		&params.Rule,
+		"rule",
+		"",
+		"Specify the rule in B/S notation, e.g. B36/S23 for HighLife. Defaults to the rule of an rle input or B3/S23.")
+
+	topology := flag.String(
+		"topology",
+		gol.Torus.String(),
+		"Specify how the edges of the board are joined: torus, plane, klein, cylinder or projective. Defaults to torus.")
+
+	engine := flag.String(
+		"engine",
+		gol.RowStrips.String(),
+		"Specify how turns are calculated: strips or hashlife. Defaults to strips.")
+
+	flag.IntVar(
+		&params.HashLifeStep,
+		"step",
+		0,
+		"Specify log2 of the most turns the hashlife engine may jump at once. Defaults to 0.")
+
+	flag.StringVar(
+		&params.Broker,
+		"broker",
+		"",
+		"Specify the address of a broker to run the turns on, e.g. 127.0.0.1:8030. Defaults to running locally.")
+
+	attach := flag.String(
+		"attach",
+		"",
+		"Specify the address of a broker to take over the world it is running after a previous controller quit.")
+
+	flag.IntVar(
+		&params.CheckpointInterval,
+		"checkpoint",
+		0,
+		"Specify how many turns to leave between checkpoints, 0 only writes one on 's'. Defaults to 0.")
+
+	flag.StringVar(
+		&params.Resume,
+		"resume",
+		"",
+		"Specify the path of a checkpoint to carry on from instead of loading an image.")
+
+	flag.StringVar(
+		&params.Input,
+		"in",
+		"",
+		"Specify the path of a pgm or pbm image or .rle, .cells or .lif pattern to load. The size of an image replaces -w and -h, a pattern is placed on the board. Defaults to images/<w>x<h>.pgm.")
+
+	flag.Float64Var(
+		&params.Threshold,
+		"threshold",
+		0,
+		"Specify the fraction of the maxval of an image that a pixel has to be above to be alive. Defaults to 0.")
+
+	flag.BoolVar(
+		&params.PBM,
+		"pbm",
+		false,
+		"Write images as 1-bit P4 .pbm files instead of P5 .pgm files.")
+
+	origin := flag.String(
+		"origin",
+		"0,0",
+		"Specify the cell x,y that (0, 0) of a Life 1.06 pattern is placed on. Defaults to 0,0.")
+
+	formats := flag.String(
+		"formats",
+		"rle",
+		"Specify the pattern formats to write next to every pgm image, e.g. rle,cells,lif. Defaults to rle.")
+
+	flag.StringVar(
+		&params.Record.Path,
+		"record",
+		"",
+		"Specify a .gif file to record the run to, or a .png file to write numbered frames next to. Defaults to no recording.")
+
+	flag.IntVar(
+		&params.Record.Every,
+		"recordEvery",
+		1,
+		"Specify the number of turns between recorded frames. Defaults to 1.")
+
+	flag.IntVar(
+		&params.Record.Scale,
+		"recordScale",
+		4,
+		"Specify the size in pixels of a recorded cell. Defaults to 4.")
+
+	recordAlive := flag.String(
+		"recordAlive",
+		"#ffffff",
+		"Specify the colour of alive cells in the recording. Defaults to #ffffff.")
+
+	recordDead := flag.String(
+		"recordDead",
+		"#000000",
+		"Specify the colour of dead cells in the recording. Defaults to #000000.")
+
+	flag.IntVar(
+		&params.Record.Delay,
+		"recordDelay",
+		10,
+		"Specify the time between frames of a gif in hundredths of a second. Defaults to 10.")
+
+	flag.IntVar(
+		&params.Record.MaxFrames,
+		"recordFrames",
+		0,
+		"Specify the most frames to record, 0 records until the end of the run. Defaults to 0.")
+
+	recordMemory := flag.Int(
+		"recordMemory",
+		gol.DefaultGIFBytes>>20,
+		"Specify the most MiB the frames of a gif take up in memory before it stops recording. Defaults to 256.")
+
+	flag.IntVar(
+		&params.History,
+		"history",
+		100,
+		"Specify how many earlier turns and edits to keep so that b can undo them while paused. Defaults to 100.")
+
+	flag.Float64Var(
+		&params.TurnsPerSecond,
+		"tps",
+		0,
+		"Specify the most turns to run per second, + and - change it while running. Defaults to 0, which is unlimited.")
+
+	flag.IntVar(
+		&params.DetectCycles,
+		"cycles",
+		0,
+		"Specify the longest period of cycle to look for, 0 does not look. Defaults to 0.")
+
+	flag.BoolVar(
+		&params.FastForward,
+		"fastForward",
+		false,
+		"Skip to the last turn once a cycle has been found.")
+
+	flag.BoolVar(
+		&params.Census,
+		"census",
+		false,
+		"Count the objects on the final board, such as blocks and gliders.")
+
+	flag.DurationVar(
+		&params.AliveCellsPeriod,
+		"aliveEvery",
+		0,
+		"Specify how often to report the number of alive cells, e.g. 500ms, a negative period reports none. Defaults to 2s.")
+
+	flag.IntVar(
+		&params.AliveCellsTurns,
+		"aliveTurns",
+		0,
+		"Specify the number of turns between reports of the number of alive cells instead of a period of time. Defaults to 0.")
+
+	flag.StringVar(
+		&params.Stats,
+		"stats",
+		"",
+		"Specify a csv file to write the population, births, deaths, bounding box and density of every turn to. Defaults to none.")
+
+	soup := flag.String(
+		"soup",
+		"",
+		"Fill the board at random instead of loading an image, given as density,seed such as 0.35,42. Defaults to no soup.")
+
+	batch := flag.Int(
+		"batch",
+		0,
+		"Run this many soups with the seeds from -soup upwards, -t at a time, and write out/soups.csv instead of showing a run.")
+
+	terminal := flag.String(
+		"tui",
+		"",
+		"Draw the board in the terminal instead of the SDL window, with half or braille characters. Defaults to the SDL window.")
+
+	scale := flag.Int(
+		"scale",
+		0,
+		"Specify the size in pixels of a cell in the window, 0 picks the largest that fits on the screen. Defaults to 0.")
+
+	colour := flag.String(
+		"colour",
+		"plain",
+		"Specify the colour mode of the window: plain, age, heat or diff. Press c to change it. Defaults to plain.")
+
+	fps := flag.Int(
+		"fps",
+		0,
+		"Specify how many frames per second the window draws, so that the turns can run ahead of it. Defaults to 0, which draws every turn.")
+
 	noVis := flag.Bool(
 		"noVis",
 		false,
@@ -45,24 +245,191 @@ func main() {
 
 	flag.Parse()
 
+	// the window needs the size of the checkpoint before the simulation starts
+	if params.Resume != "" {
+		resumed, turn, err := gol.ReadCheckpointParams(params.Resume)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		params.ImageWidth, params.ImageHeight = resumed.ImageWidth, resumed.ImageHeight
+		params.Rule = resumed.Rule
+		*topology = resumed.Topology.String()
+		fmt.Println("Resuming from turn:", turn)
+	}
+
+	// the window needs the size of the image before the simulation starts,
+	// a pattern is placed on a board of the given size instead
+	if params.Input != "" && params.Resume == "" {
+		if gol.IsPattern(params.Input) {
+			_, _, rule, err := gol.ReadPatternHeader(params.Input)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if params.Rule == "" {
+				params.Rule = rule
+			}
+		} else {
+			width, height, err := gol.ReadImageSize(params.Input)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			params.ImageWidth, params.ImageHeight = width, height
+		}
+	}
+
+	rule, err := gol.ParseRule(params.Rule)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	params.Topology, err = gol.ParseTopology(*topology)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// 'go run . census out/64x64x100.pgm' counts the objects in images instead of running
+	if flag.Arg(0) == "census" {
+		if err := runCensus(flag.Args()[1:], rule, params); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	params.Engine, err = gol.ParseEngine(*engine)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	var style tui.Style
+	if *terminal != "" {
+		style, err = tui.ParseStyle(*terminal)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if *soup != "" {
+		params.Soup, err = gol.ParseSoup(*soup)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if *batch > 0 {
+		if err := runBatch(params, *batch); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	window := sdl.Options{Scale: *scale, FPS: *fps}
+	window.Mode, err = sdl.ParseColourMode(*colour)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	params.OutputFormats, err = gol.ParseFormats(*formats)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	params.Record.Alive, err = gol.ParseColour(*recordAlive)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	params.Record.Dead, err = gol.ParseColour(*recordDead)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	params.Record.MaxBytes = *recordMemory << 20
+
+	if _, err := fmt.Sscanf(*origin, "%d,%d", &params.Origin.X, &params.Origin.Y); err != nil {
+		fmt.Println("bad origin:", *origin)
+		os.Exit(1)
+	}
+
+	if *attach != "" {
+		params.Broker = *attach
+		params.Attach = true
+	}
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
+	fmt.Println("Rule:", rule)
+	fmt.Println("Topology:", params.Topology)
+	fmt.Println("Engine:", params.Engine)
 
 	keyPresses := make(chan rune, 10)
 	events := make(chan gol.Event, 1000)
 
-	go gol.Run(params, events, keyPresses)
-	if !(*noVis) {
-		sdl.Run(params, events, keyPresses)
+	edits := make(chan util.Cell, 1000)
+
+	go gol.RunWithEdits(params, events, keyPresses, edits)
+	if *terminal != "" {
+		tui.Run(params, events, keyPresses, style)
+	} else if !(*noVis) {
+		sdl.Run(params, events, keyPresses, edits, window)
 	} else {
 		complete := false
 		for !complete {
 			event := <-events
-			switch event.(type) {
+			switch e := event.(type) {
 			case gol.FinalTurnComplete:
 				complete = true
+			case gol.ErrorOccurred:
+				fmt.Println(e)
+				os.Exit(1)
 			}
 		}
 	}
 }
+
+// runCensus prints the objects in every image, using the rule, topology and threshold in the params.
+func runCensus(paths []string, rule gol.Rule, p gol.Params) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("census needs at least one image")
+	}
+	for _, path := range paths {
+		width, height, alive, err := gol.ReadAliveCells(path, p.Threshold)
+		if err != nil {
+			return err
+		}
+		counts := census.Take(width, height, p.Topology.Wrap(width, height), census.Rule(rule), alive)
+		fmt.Printf("%v: %v\n", path, census.Summary(counts))
+	}
+	return nil
+}
+
+// runBatch writes the results of a batch of soups to out/soups.csv.
+func runBatch(p gol.Params, count int) error {
+	if p.Soup.Density == 0 {
+		return fmt.Errorf("-batch needs a -soup")
+	}
+	_ = os.MkdirAll("out", os.ModePerm)
+	file, err := os.Create("out/soups.csv")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := gol.RunBatch(p, count, file); err != nil {
+		return err
+	}
+	fmt.Println("Batch of", count, "soups written to out/soups.csv")
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestNetpbm tests that the 64x64 image gives the same boards when it is stored in every netpbm format,
+// including grey levels that need a threshold and binary pixels that look like whitespace.
+func TestNetpbm(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		threshold float64
+	}{
+		{"64x64-p1.pbm", 0},
+		{"64x64-p2.pgm", 0.5},
+		{"64x64-p4.pbm", 0},
+		{"64x64-p5-16bit.pgm", 0.5},
+		{"64x64-p5-whitespace.pgm", 0},
+	}
+	for _, test := range tests {
+		for _, turns := range []int{0, 100} {
+			p := gol.Params{
+				Turns:     turns,
+				Threads:   4,
+				Input:     "check/netpbm/" + test.fixture,
+				Threshold: test.threshold,
+			}
+			t.Run(fmt.Sprintf("%s-%d", test.fixture, turns), func(t *testing.T) {
+				expected := readAliveCells(fmt.Sprintf("check/images/64x64x%d.pgm", turns), 64, 64)
+				p.ImageWidth, p.ImageHeight = 64, 64
+				assertEqualBoard(t, runFinal(t, p), expected, p)
+			})
+		}
+	}
+}
+
+// TestPBMOutput tests that images written as P4, both by the distributor and by WritePattern, load back into the same board.
+func TestPBMOutput(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64, PBM: true}
+	expected := readAliveCells("check/images/64x64x100.pgm", 64, 64)
+	assertEqualBoard(t, runFinal(t, p), expected, p)
+
+	if err := gol.WritePattern("out/final-64x64x100.pbm", p, expected); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"out/64x64x100.pbm", "out/final-64x64x100.pbm"} {
+		loaded := gol.Params{Threads: 1, Input: path}
+		cells := runFinal(t, loaded)
+		loaded.ImageWidth, loaded.ImageHeight = 64, 64
+		assertEqualBoard(t, cells, expected, loaded)
+	}
+}
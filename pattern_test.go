@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// runFinal runs the params and returns the alive cells from FinalTurnComplete.
+func runFinal(t *testing.T, p gol.Params) []util.Cell {
+	events := make(chan gol.Event)
+	go gol.Run(p, events, nil)
+	var cells []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	}
+	return cells
+}
+
+// TestPatternFormats tests that the Gosper glider gun ends on the same board from every pattern format.
+// The Life 1.06 file is centred on (0, 0), so its origin is the middle of where the other formats are placed.
+func TestPatternFormats(t *testing.T) {
+	tests := []struct {
+		path   string
+		origin util.Cell
+	}{
+		{"check/patterns/gosperglidergun.rle", util.Cell{}},
+		{"check/patterns/gosperglidergun.cells", util.Cell{}},
+		{"check/patterns/gosperglidergun.lif", util.Cell{X: 32, Y: 23}},
+	}
+	for _, test := range tests {
+		for _, turns := range []int{0, 100} {
+			p := gol.Params{
+				Turns:       turns,
+				Threads:     4,
+				ImageWidth:  64,
+				ImageHeight: 48,
+				Input:       test.path,
+				Origin:      test.origin,
+			}
+			t.Run(fmt.Sprintf("%s-%d", test.path, turns), func(t *testing.T) {
+				expected := fmt.Sprintf("check/patterns/gosperglidergun-64x48x%d.pgm", turns)
+				assertEqualBoard(t, runFinal(t, p), readAliveCells(expected, 64, 48), p)
+			})
+		}
+	}
+}
+
+// TestPatternSize tests that a Life 1.06 file with negative coordinates and no board size in the params
+// gets a board the size of its bounding box, with the pattern filling it just like the rle does.
+func TestPatternSize(t *testing.T) {
+	for _, turns := range []int{0, 30} {
+		p := gol.Params{Turns: turns, Threads: 4, Input: "check/patterns/gosperglidergun.lif"}
+		expected := gol.Params{Turns: turns, Threads: 4, ImageWidth: 36, ImageHeight: 9, Input: "check/patterns/gosperglidergun.rle"}
+		t.Run(fmt.Sprintf("%d", turns), func(t *testing.T) {
+			assertEqualBoard(t, runFinal(t, p), runFinal(t, expected), expected)
+		})
+	}
+}
+
+// TestPatternOutput tests that every output format loads back into the final board of a 64x64 image,
+// including Life 1.06 with an origin in the middle of the board.
+func TestPatternOutput(t *testing.T) {
+	p := gol.Params{
+		Turns:         100,
+		Threads:       4,
+		ImageWidth:    64,
+		ImageHeight:   64,
+		Origin:        util.Cell{X: 32, Y: 32},
+		OutputFormats: []string{"rle", "cells", "lif"},
+	}
+	expected := readAliveCells("check/images/64x64x100.pgm", 64, 64)
+	assertEqualBoard(t, runFinal(t, p), expected, p)
+
+	for _, extension := range p.OutputFormats {
+		t.Run(extension, func(t *testing.T) {
+			loaded := gol.Params{
+				Threads:     1,
+				ImageWidth:  64,
+				ImageHeight: 64,
+				Input:       "out/64x64x100." + extension,
+				Origin:      p.Origin,
+			}
+			assertEqualBoard(t, runFinal(t, loaded), expected, loaded)
+		})
+	}
+}
+
+// TestWritePattern tests that the cells from FinalTurnComplete can be written straight to a file in every format.
+func TestWritePattern(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64}
+	cells := runFinal(t, p)
+	for _, extension := range []string{"pgm", "rle", "cells", "lif"} {
+		t.Run(extension, func(t *testing.T) {
+			path := "out/final-64x64x100." + extension
+			if err := gol.WritePattern(path, p, cells); err != nil {
+				t.Fatal(err)
+			}
+			loaded := gol.Params{Threads: 1, ImageWidth: 64, ImageHeight: 64, Input: path}
+			assertEqualBoard(t, runFinal(t, loaded), cells, loaded)
+		})
+	}
+}
+
+// TestPatternErrors tests that unknown formats and cells that land outside the board are reported as errors.
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		p        gol.Params
+		expected error
+	}{
+		{gol.Params{ImageWidth: 64, ImageHeight: 64, OutputFormats: []string{"gif"}}, gol.ErrUnknownFormat},
+		{gol.Params{ImageWidth: 64, ImageHeight: 48, Input: "check/patterns/gosperglidergun.lif"}, gol.ErrDimensionMismatch},
+	}
+	for _, test := range tests {
+		if err := runUntilError(t, test.p); !errors.Is(err, test.expected) {
+			t.Fatalf("Expected %v, got %v", test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// frameCells returns the cells of a recorded frame that have the alive colour.
+func frameCells(img image.Image, scale int, alive color.Color) []util.Cell {
+	r, g, b, _ := alive.RGBA()
+	var cells []util.Cell
+	bounds := img.Bounds()
+	for y := 0; y < bounds.Dy()/scale; y++ {
+		for x := 0; x < bounds.Dx()/scale; x++ {
+			// every pixel of a cell has to be the same colour
+			first := img.At(x*scale, y*scale)
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					if img.At(x*scale+dx, y*scale+dy) != first {
+						panic(fmt.Sprintf("cell (%d, %d) is not a single colour", x, y))
+					}
+				}
+			}
+			pr, pg, pb, _ := first.RGBA()
+			if pr == r && pg == g && pb == b {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
+
+// TestRecordGIF tests that a gif has a frame for the loaded world and every 10 turns after it,
+// scaled and coloured as asked, and that MaxFrames cuts it short.
+func TestRecordGIF(t *testing.T) {
+	alive := color.RGBA{R: 255, G: 200, A: 255}
+	dead := color.RGBA{B: 80, A: 255}
+	for _, maxFrames := range []int{0, 5} {
+		p := gol.Params{
+			Turns:       100,
+			Threads:     4,
+			ImageWidth:  16,
+			ImageHeight: 16,
+			Record: gol.Recording{
+				Path:      fmt.Sprintf("out/record-%d.gif", maxFrames),
+				Every:     10,
+				Scale:     3,
+				Alive:     alive,
+				Dead:      dead,
+				Delay:     7,
+				MaxFrames: maxFrames,
+			},
+		}
+		t.Run(p.Record.Path, func(t *testing.T) {
+			runFinal(t, p)
+			file, err := os.Open(p.Record.Path)
+			util.Check(err)
+			defer file.Close()
+			recording, err := gif.DecodeAll(file)
+			util.Check(err)
+
+			expectedFrames := 11
+			if maxFrames > 0 {
+				expectedFrames = maxFrames
+			}
+			if len(recording.Image) != expectedFrames {
+				t.Fatalf("Expected %v frames, got %v", expectedFrames, len(recording.Image))
+			}
+			for i, frame := range recording.Image {
+				if frame.Bounds().Dx() != 48 || frame.Bounds().Dy() != 48 {
+					t.Fatalf("Frame %v is %v, expected 48x48", i, frame.Bounds())
+				}
+				if recording.Delay[i] != 7 {
+					t.Fatalf("Frame %v has a delay of %v, expected 7", i, recording.Delay[i])
+				}
+			}
+
+			assertEqualBoard(t, frameCells(recording.Image[0], 3, alive), readAliveCells("check/images/16x16x0.pgm", 16, 16), p)
+			if maxFrames == 0 {
+				last := recording.Image[len(recording.Image)-1]
+				assertEqualBoard(t, frameCells(last, 3, alive), readAliveCells("check/images/16x16x100.pgm", 16, 16), p)
+			}
+		})
+	}
+}
+
+// TestRecordGIFMaxBytes tests that a gif stops once its frames would take up more than MaxBytes
+// rather than holding every turn of a long run in memory.
+func TestRecordGIFMaxBytes(t *testing.T) {
+	// every 16x16 frame at scale 2 takes up 1024 bytes
+	p := gol.Params{
+		Turns:       1000,
+		Threads:     4,
+		ImageWidth:  16,
+		ImageHeight: 16,
+		Record:      gol.Recording{Path: "out/record-bytes.gif", Scale: 2, MaxBytes: 10*1024 + 1000},
+	}
+	runFinal(t, p)
+	file, err := os.Open(p.Record.Path)
+	util.Check(err)
+	defer file.Close()
+	recording, err := gif.DecodeAll(file)
+	util.Check(err)
+	if len(recording.Image) != 10 {
+		t.Fatalf("Expected 10 frames, got %v", len(recording.Image))
+	}
+}
+
+// TestRecordPNG tests that a png recording writes a frame named after its turn every 50 turns.
+func TestRecordPNG(t *testing.T) {
+	p := gol.Params{
+		Turns:       100,
+		Threads:     4,
+		ImageWidth:  64,
+		ImageHeight: 64,
+		Record:      gol.Recording{Path: "out/frames/record.png", Every: 50},
+	}
+	runFinal(t, p)
+	for _, turn := range []int{0, 50, 100} {
+		file, err := os.Open(fmt.Sprintf("out/frames/record-%06d.png", turn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame, err := png.Decode(file)
+		file.Close()
+		util.Check(err)
+		cells := frameCells(frame, 1, color.White)
+		if alive := readAliveCounts(64, 64); turn > 0 && len(cells) != alive[turn] {
+			t.Fatalf("At turn %v expected %v alive cells, got %v instead", turn, alive[turn], len(cells))
+		}
+		if turn == 100 {
+			assertEqualBoard(t, cells, readAliveCells("check/images/64x64x100.pgm", 64, 64), p)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestRLE tests that rle patterns from LifeWiki are placed in the middle of the board
+// and run with the rule from their header.
+func TestRLE(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		turns         int
+	}{
+		{"gosperglidergun", 64, 48, 0},
+		{"gosperglidergun", 64, 48, 100},
+		{"replicator", 64, 64, 0},
+		{"replicator", 64, 64, 60},
+	}
+	for _, test := range tests {
+		p := gol.Params{
+			Turns:       test.turns,
+			Threads:     4,
+			ImageWidth:  test.width,
+			ImageHeight: test.height,
+			Input:       "check/patterns/" + test.name + ".rle",
+		}
+		t.Run(fmt.Sprintf("%s-%dx%dx%d", test.name, test.width, test.height, test.turns), func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			var cells []util.Cell
+			for event := range events {
+				switch e := event.(type) {
+				case gol.ErrorOccurred:
+					t.Fatal(e.Err)
+				case gol.FinalTurnComplete:
+					cells = e.Alive
+				}
+			}
+			expected := fmt.Sprintf("check/patterns/%s-%dx%dx%d.pgm", test.name, test.width, test.height, test.turns)
+			assertEqualBoard(t, cells, readAliveCells(expected, test.width, test.height), p)
+		})
+	}
+}
+
+// TestRLERoundTrip tests that the rle written by 's' loads back into the same board as the pgm written next to it.
+func TestRLERoundTrip(t *testing.T) {
+	p := gol.Params{Turns: 100000, Threads: 4, ImageWidth: 64, ImageHeight: 64, OutputFormats: []string{"rle"}}
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 3)
+	go gol.Run(p, events, keyPresses)
+	savedTurn := -1
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			if e.CompletedTurns == 50 {
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			switch e.NewState {
+			case gol.Paused:
+				savedTurn = e.CompletedTurns
+				keyPresses <- 's'
+				keyPresses <- 'p'
+			case gol.Executing:
+				keyPresses <- 'q'
+			}
+		}
+	}
+	if savedTurn == -1 {
+		t.Fatal("The board was never paused")
+	}
+
+	loaded := gol.Params{
+		Turns:       0,
+		Threads:     1,
+		ImageWidth:  64,
+		ImageHeight: 64,
+		Input:       fmt.Sprintf("out/64x64x%d.rle", savedTurn),
+	}
+	events = make(chan gol.Event)
+	go gol.Run(loaded, events, nil)
+	var cells []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	}
+	expected := readAliveCells(fmt.Sprintf("out/64x64x%d.pgm", savedTurn), 64, 64)
+	assertEqualBoard(t, cells, expected, loaded)
+	if alive := readAliveCounts(64, 64); len(cells) != alive[savedTurn] {
+		t.Fatalf("At turn %v expected %v alive cells, got %v instead", savedTurn, alive[savedTurn], len(cells))
+	}
+}
+
+// TestRLECorrupt tests that bad rle patterns are reported as errors.
+func TestRLECorrupt(t *testing.T) {
+	tests := []struct {
+		path     string
+		size     int
+		expected error
+	}{
+		{"check/corrupt/unterminated.rle", 16, gol.ErrBadPattern},
+		{"check/patterns/gosperglidergun.rle", 16, gol.ErrDimensionMismatch},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			p := gol.Params{Turns: 10, Threads: 2, ImageWidth: test.size, ImageHeight: test.size, Input: test.path}
+			if err := runUntilError(t, p); !errors.Is(err, test.expected) {
+				t.Fatalf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// TestSaveExecuting tests that 's' while executing writes the pgm, checkpoint and rle for the same turn.
+// The 'k' right after it is handled before another turn, so it quits on the turn that was saved.
+func TestSaveExecuting(t *testing.T) {
+	p := gol.Params{Turns: 99999, Threads: 4, ImageWidth: 64, ImageHeight: 64, OutputFormats: []string{"rle"}}
+	_ = os.Remove("out/64x64x99999.pgm")
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 2)
+	go gol.Run(p, events, keyPresses)
+	savedTurn := -1
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.TurnComplete:
+			if e.CompletedTurns == 50 {
+				keyPresses <- 's'
+				keyPresses <- 'k'
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Quitting {
+				savedTurn = e.CompletedTurns
+			}
+		}
+	}
+	if _, err := os.Stat("out/64x64x99999.pgm"); err == nil {
+		t.Fatal("'s' saved the pgm with the number of turns instead of the current turn")
+	}
+
+	_, turn, err := gol.ReadCheckpointParams(fmt.Sprintf("out/64x64x%d.checkpoint", savedTurn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turn != savedTurn {
+		t.Fatalf("The checkpoint is for turn %v, expected turn %v", turn, savedTurn)
+	}
+
+	loaded := gol.Params{Turns: 0, Threads: 1, ImageWidth: 64, ImageHeight: 64, Input: fmt.Sprintf("out/64x64x%d.rle", savedTurn)}
+	events = make(chan gol.Event)
+	go gol.Run(loaded, events, nil)
+	var cells []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.FinalTurnComplete:
+			cells = e.Alive
+		}
+	}
+	assertEqualBoard(t, cells, readAliveCells(fmt.Sprintf("out/64x64x%d.pgm", savedTurn), 64, 64), loaded)
+	if alive := readAliveCounts(64, 64); len(cells) != alive[savedTurn] {
+		t.Fatalf("At turn %v expected %v alive cells, got %v instead", savedTurn, alive[savedTurn], len(cells))
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestRule tests 16x16 and 64x64 images on 0, 1 and 100 turns under HighLife, Day & Night and Seeds using 1-8 worker threads.
+func TestRule(t *testing.T) {
+	tests := []gol.Params{
+		{ImageWidth: 16, ImageHeight: 16},
+		{ImageWidth: 64, ImageHeight: 64},
+	}
+	for _, rule := range []string{"B36/S23", "B3678/S34678", "B2/S"} {
+		for _, p := range tests {
+			p.Rule = rule
+			for _, turns := range []int{0, 1, 100} {
+				p.Turns = turns
+				expectedAlive := readAliveCells(
+					"check/rules/"+strings.Replace(rule, "/", "", 1)+"/"+fmt.Sprintf("%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, turns),
+					p.ImageWidth,
+					p.ImageHeight,
+				)
+				for threads := 1; threads <= 8; threads++ {
+					p.Threads = threads
+					testName := fmt.Sprintf("%v-%dx%dx%d-%d", rule, p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+					t.Run(testName, func(t *testing.T) {
+						events := make(chan gol.Event)
+						go gol.Run(p, events, nil)
+						var cells []util.Cell
+						for event := range events {
+							switch e := event.(type) {
+							case gol.FinalTurnComplete:
+								cells = e.Alive
+							}
+						}
+						assertEqualBoard(t, cells, expectedAlive, p)
+					})
+				}
+			}
+		}
+	}
+}
+
+// TestParseRule tests that valid rulestrings are normalised and invalid ones are rejected.
+func TestParseRule(t *testing.T) {
+	valid := map[string]string{
+		"":             "B3/S23",
+		"B3/S23":       "B3/S23",
+		"b36/s23":      "B36/S23",
+		"S23/B36":      "B36/S23",
+		"23/36":        "B36/S23",
+		"B2/S":         "B2/S",
+		"B3678/S34678": "B3678/S34678",
+	}
+	for s, expected := range valid {
+		rule, err := gol.ParseRule(s)
+		if err != nil {
+			t.Errorf("ParseRule(%q) returned error: %v", s, err)
+		} else if rule.String() != expected {
+			t.Errorf("ParseRule(%q) = %v, expected %v", s, rule, expected)
+		}
+	}
+
+	for _, s := range []string{"B3", "B3/S23/B2", "B9/S23", "B3/B23", "X3/S23", "B33/S23", "B3/"} {
+		if _, err := gol.ParseRule(s); err == nil {
+			t.Errorf("ParseRule(%q) should have returned an error", s)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package sdl
+
+import (
+	"fmt"
+	"math"
+)
+
+// ColourMode chooses how cells are coloured in the window.
+type ColourMode int
+
+const (
+	// Plain draws alive cells white and dead cells black.
+	Plain ColourMode = iota
+	// Age colours alive cells by how many turns they have been alive, from white through red to blue.
+	Age
+	// Heat shows how often cells have flipped recently, fading out as a cell stays the same.
+	Heat
+	// Diff highlights the cells born on the current turn in green and the ones that died in red.
+	Diff
+	colourModes
+)
+
+func (mode ColourMode) String() string {
+	switch mode {
+	case Plain:
+		return "plain"
+	case Age:
+		return "age"
+	case Heat:
+		return "heat"
+	case Diff:
+		return "diff"
+	}
+	return fmt.Sprintf("ColourMode(%d)", int(mode))
+}
+
+// next returns the mode after this one, going back to Plain after the last.
+func (mode ColourMode) next() ColourMode {
+	return (mode + 1) % colourModes
+}
+
+// ParseColourMode parses the name of a colour mode, such as "age".
+func ParseColourMode(s string) (ColourMode, error) {
+	for mode := Plain; mode < colourModes; mode++ {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+	return Plain, fmt.Errorf("unknown colour mode %q, expected plain, age, heat or diff", s)
+}
+
+// heatDecay is how much of the heat of a cell is left after a turn.
+const heatDecay = 0.9
+
+type rgb struct{ r, g, b uint8 }
+
+var (
+	black = rgb{}
+	white = rgb{0xFF, 0xFF, 0xFF}
+	// the colours of alive cells that have not changed in the heat and diff modes
+	dim  = rgb{0x40, 0x40, 0x40}
+	grey = rgb{0x80, 0x80, 0x80}
+	born = rgb{0x20, 0xE0, 0x20}
+	died = rgb{0xE0, 0x20, 0x20}
+
+	ageGradient  = []rgb{white, {0xFF, 0xE0, 0x40}, {0xFF, 0x80, 0x00}, {0xD0, 0x20, 0x20}, {0x80, 0x20, 0xA0}, {0x20, 0x40, 0xC0}}
+	heatGradient = []rgb{black, {0xA0, 0x00, 0x00}, {0xFF, 0x60, 0x00}, {0xFF, 0xE0, 0x40}, white}
+)
+
+// gradient returns the colour a fraction t of the way along the stops, t is clamped to [0, 1].
+func gradient(stops []rgb, t float64) rgb {
+	t = math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+	i := int(t)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1]
+	}
+	f := t - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f)
+	}
+	return rgb{lerp(stops[i].r, stops[i+1].r), lerp(stops[i].g, stops[i+1].g), lerp(stops[i].b, stops[i+1].b)}
+}
+
+// ageColour uses a log scale so that a cell changes colour quickly when young
+// and slowly once it has been alive for hundreds of turns.
+func ageColour(age int) rgb {
+	// stepping back can leave a cell born after the current turn
+	if age < 0 {
+		age = 0
+	}
+	return gradient(ageGradient, math.Log2(float64(age)+1)/10)
+}
+
+// heatColour maps the heat of a cell, which is roughly the number of recent flips, onto the heat gradient.
+func heatColour(heat float32) rgb {
+	return gradient(heatGradient, 1-math.Exp(-float64(heat)/2))
+}
+
+// cell is what the window remembers about a cell to colour it.
+type cell struct {
+	alive bool
+	// changed is the turn the cell last flipped on
+	changed int
+	// heat is the heat of the cell on turn heated, it cools down from there when it is next needed
+	heat   float32
+	heated int
+}
+
+// heatAt returns the heat of the cell on the given turn, cooling it down for the turns since it was last heated.
+func (c cell) heatAt(turn int) float32 {
+	if turn <= c.heated {
+		return c.heat
+	}
+	return c.heat * float32(math.Pow(heatDecay, float64(turn-c.heated)))
+}
+
+// flip flips the cell on the given turn, which also heats it up.
+func (c *cell) flip(turn int) {
+	c.alive = !c.alive
+	c.changed = turn
+	c.heat = c.heatAt(turn) + 1
+	c.heated = turn
+}
+
+// colour returns the colour of a cell in the given mode on the given turn.
+func (c cell) colour(mode ColourMode, turn int) rgb {
+	switch mode {
+	case Age:
+		if c.alive {
+			return ageColour(turn - c.changed)
+		}
+	case Heat:
+		if colour := heatColour(c.heatAt(turn)); colour != black || !c.alive {
+			return colour
+		}
+		return dim
+	case Diff:
+		if c.changed == turn {
+			if c.alive {
+				return born
+			}
+			return died
+		}
+		if c.alive {
+			return grey
+		}
+	default:
+		if c.alive {
+			return white
+		}
+	}
+	return black
+}
@@ -3,11 +3,76 @@ package sdl
 import (
 	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
+	"time"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
-func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
-	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+// painter turns a left click and drag into edits while the run is paused.
+// The clicked cell is toggled and every cell dragged over afterwards is given the same state.
+type painter struct {
+	w       *Window
+	edits   chan<- util.Cell
+	drawing bool
+	alive   bool
+	sent    map[util.Cell]bool
+}
+
+func (pt *painter) send(cell util.Cell) {
+	if pt.sent[cell] || cell.X < 0 || cell.Y < 0 || cell.X >= int(pt.w.Width) || cell.Y >= int(pt.w.Height) {
+		return
+	}
+	pt.sent[cell] = true
+	// drop the edit rather than block if the distributor is busy
+	select {
+	case pt.edits <- cell:
+	default:
+	}
+}
+
+func (pt *painter) press(x, y int32) {
+	cellX, cellY := pt.w.CellAt(x, y)
+	cell := util.Cell{X: cellX, Y: cellY}
+	pt.drawing = true
+	pt.alive = !pt.w.CellAlive(cell.X, cell.Y)
+	pt.sent = make(map[util.Cell]bool)
+	pt.send(cell)
+}
+
+func (pt *painter) drag(x, y int32) {
+	cellX, cellY := pt.w.CellAt(x, y)
+	cell := util.Cell{X: cellX, Y: cellY}
+	if pt.drawing && pt.w.CellAlive(cell.X, cell.Y) != pt.alive {
+		pt.send(cell)
+	}
+}
+
+// Options are the settings of the window.
+type Options struct {
+	// Scale is the number of pixels per cell, 0 picks the largest scale that fits on the screen.
+	Scale int
+	// Mode is the colour mode the window starts in.
+	Mode ColourMode
+	// FPS draws frames at a fixed rate instead of after every turn, so that the engine can run ahead of the window.
+	// 0 draws every turn.
+	FPS int
+}
+
+// Run shows the board in a window. The mouse wheel zooms, dragging pans, 'f' fits the board to the window,
+// '0' goes back to the starting scale and 'c' cycles through the colour modes. '+' and '-' change the speed of the run.
+// While paused, dragging with the left button edits cells instead.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, edits chan<- util.Cell, options Options) {
+	w := NewScaledWindow(int32(p.ImageWidth), int32(p.ImageHeight), int32(options.Scale))
+	w.SetMode(options.Mode)
+	pt := &painter{w: w, edits: edits}
+	paused := false
+	var frame time.Duration
+	if options.FPS > 0 {
+		frame = time.Second / time.Duration(options.FPS)
+	}
+	// lastFrame is when the last frame was drawn, pending is set if a turn has completed since
+	lastFrame := time.Now()
+	pending := false
 
 sdlLoop:
 	for {
@@ -24,6 +89,51 @@ sdlLoop:
 					keyPresses <- 'q'
 				case sdl.K_k:
 					keyPresses <- 'k'
+				case sdl.K_n:
+					keyPresses <- 'n'
+				case sdl.K_b:
+					keyPresses <- 'b'
+				case sdl.K_PLUS, sdl.K_EQUALS, sdl.K_KP_PLUS:
+					keyPresses <- '+'
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					keyPresses <- '-'
+				case sdl.K_f:
+					w.Fit()
+					w.RenderFrame()
+				case sdl.K_0:
+					w.ResetZoom()
+					w.RenderFrame()
+				case sdl.K_c:
+					w.SetMode(w.Mode().next())
+					fmt.Println("Colour mode:", w.Mode())
+					w.RenderFrame()
+				}
+			case *sdl.MouseButtonEvent:
+				if e.Button == sdl.BUTTON_LEFT {
+					if e.Type == sdl.MOUSEBUTTONDOWN && paused {
+						pt.press(e.X, e.Y)
+					} else {
+						pt.drawing = false
+					}
+				}
+			case *sdl.MouseMotionEvent:
+				if paused && pt.drawing {
+					pt.drag(e.X, e.Y)
+				} else if e.State&(sdl.ButtonRMask()|sdl.ButtonMMask()) != 0 || (!paused && e.State&sdl.ButtonLMask() != 0) {
+					w.Pan(e.XRel, e.YRel)
+					w.RenderFrame()
+				}
+			case *sdl.MouseWheelEvent:
+				x, y, _ := sdl.GetMouseState()
+				steps := int(e.Y)
+				if e.Direction == sdl.MOUSEWHEEL_FLIPPED {
+					steps = -steps
+				}
+				w.ZoomAt(x, y, steps)
+				w.RenderFrame()
+			case *sdl.WindowEvent:
+				if e.Event == sdl.WINDOWEVENT_SIZE_CHANGED {
+					w.RenderFrame()
 				}
 			}
 		}
@@ -35,12 +145,25 @@ sdlLoop:
 			}
 			switch e := event.(type) {
 			case gol.CellFlipped:
-				w.FlipPixel(e.Cell.X, e.Cell.Y)
+				w.FlipCell(e.Cell.X, e.Cell.Y, e.CompletedTurns)
+				// no turns complete while paused, so edits are drawn straight away
+				if paused {
+					w.RenderFrame()
+				}
 			case gol.TurnComplete:
-				w.RenderFrame()
+				w.SetTurn(e.CompletedTurns)
+				if frame == 0 {
+					w.RenderFrame()
+				} else {
+					pending = true
+				}
 			case gol.FinalTurnComplete:
 				w.Destroy()
 				break sdlLoop
+			case gol.StateChange:
+				paused = e.NewState == gol.Paused
+				pt.drawing = false
+				fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
 			default:
 				if len(event.String()) > 0 {
 					fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
@@ -49,6 +172,11 @@ sdlLoop:
 		default:
 			break
 		}
+		if pending && time.Since(lastFrame) >= frame {
+			w.RenderFrame()
+			lastFrame = time.Now()
+			pending = false
+		}
 	}
 
 }
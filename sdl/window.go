@@ -2,45 +2,104 @@ package sdl
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/veandco/go-sdl2/sdl"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+// the zoom is kept to whole pixels per cell, or a whole number of cells per pixel,
+// so that every cell is drawn as a square of the same size
+const (
+	maxZoom = 64
+	minZoom = 1.0 / 64
+)
+
 type Window struct {
 	Width, Height int32
 	window        *sdl.Window
 	renderer      *sdl.Renderer
 	texture       *sdl.Texture
 	pixels        []byte
+	// cells holds the state of every cell that the pixels are coloured from
+	cells []cell
+	turn  int
+	mode  ColourMode
+	// dirty is set when the pixels have changed since they were last copied into the texture,
+	// recolour when every pixel has to be coloured again because the turn has changed
+	dirty, recolour bool
+
+	// scale is the starting number of pixels per cell, zoom is the current one
+	scale, zoom float64
+	// offsetX and offsetY are the position of the top left corner of the window in cells
+	offsetX, offsetY float64
 }
 
 func filterEvent(e sdl.Event, userdata interface{}) bool {
-	return e.GetType() == sdl.KEYDOWN || e.GetType() == sdl.QUIT
+	switch e.GetType() {
+	case sdl.KEYDOWN, sdl.QUIT, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP, sdl.MOUSEMOTION, sdl.MOUSEWHEEL, sdl.WINDOWEVENT:
+		return true
+	}
+	return false
 }
 
+// NewWindow creates a window with one pixel per cell.
 func NewWindow(width, height int32) *Window {
+	return NewScaledWindow(width, height, 1)
+}
+
+// NewScaledWindow creates a window with scale pixels per cell. A scale of 0 picks the largest scale
+// that fits on the screen. A board that does not fit on the screen is zoomed out to fit the window.
+func NewScaledWindow(width, height, scale int32) *Window {
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	util.Check(err)
-	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	screen, err := sdl.GetDisplayUsableBounds(0)
+	if err != nil {
+		screen = sdl.Rect{W: 1024, H: 768}
+	}
+	if scale < 1 {
+		scale = min32(screen.W/width, screen.H/height)
+		if scale < 1 {
+			scale = 1
+		}
+	}
+
+	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		min32(width*scale, screen.W), min32(height*scale, screen.H), sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	util.Check(err)
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.WINDOW_SHOWN)
 	util.Check(err)
-	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
-	err = renderer.SetLogicalSize(width, height)
-	util.Check(err)
+	// nearest neighbour sampling keeps the cells as sharp squares, this has to be set before the texture is created
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "nearest")
 	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC, width, height)
 	util.Check(err)
 
 	sdl.SetEventFilterFunc(filterEvent, nil)
-	return &Window{
-		width,
-		height,
-		window,
-		renderer,
-		texture,
-		make([]byte, width*height*4),
+	w := &Window{
+		Width:    width,
+		Height:   height,
+		window:   window,
+		renderer: renderer,
+		texture:  texture,
+		pixels:   make([]byte, width*height*4),
+		cells:    make([]cell, width*height),
+		dirty:    true,
+		scale:    float64(scale),
+		zoom:     float64(scale),
+	}
+	// every pixel needs an opaque colour, even dead ones
+	w.repaint()
+	if width*scale > screen.W || height*scale > screen.H {
+		w.Fit()
+	}
+	return w
+}
+
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
 	}
+	return b
 }
 
 func (w *Window) Destroy() {
@@ -54,43 +113,130 @@ func (w *Window) Destroy() {
 }
 
 func (w *Window) RenderFrame() {
-	err := w.texture.Update(nil, w.pixels, int(w.Width*4))
+	if w.recolour {
+		w.repaint()
+		w.recolour = false
+	}
+	if w.dirty {
+		err := w.texture.Update(nil, w.pixels, int(w.Width*4))
+		util.Check(err)
+		w.dirty = false
+	}
+	// the area around the board is grey so that its edges can be seen
+	err := w.renderer.SetDrawColor(0x30, 0x30, 0x30, 0xFF)
 	util.Check(err)
 	err = w.renderer.Clear()
 	util.Check(err)
-	err = w.renderer.Copy(w.texture, nil, nil)
+	board := sdl.Rect{
+		X: int32(math.Round(-w.offsetX * w.zoom)),
+		Y: int32(math.Round(-w.offsetY * w.zoom)),
+		W: int32(math.Round(float64(w.Width) * w.zoom)),
+		H: int32(math.Round(float64(w.Height) * w.zoom)),
+	}
+	err = w.renderer.Copy(w.texture, nil, &board)
 	util.Check(err)
 	w.renderer.Present()
 }
 
+// zoomStep returns the next zoom in or out from zoom. Above one pixel per cell the zoom goes up
+// a pixel at a time, below it the number of cells per pixel doubles.
+func zoomStep(zoom float64, in bool) float64 {
+	if in {
+		if zoom < 1 {
+			return zoom * 2
+		}
+		return math.Min(zoom+1, maxZoom)
+	}
+	if zoom > 1 {
+		return zoom - 1
+	}
+	return math.Max(zoom/2, minZoom)
+}
+
+// ZoomAt zooms in by steps, or out for negative steps, keeping the cell under x, y of the window in place.
+func (w *Window) ZoomAt(x, y int32, steps int) {
+	cellX, cellY := w.offsetX+float64(x)/w.zoom, w.offsetY+float64(y)/w.zoom
+	for ; steps > 0; steps-- {
+		w.zoom = zoomStep(w.zoom, true)
+	}
+	for ; steps < 0; steps++ {
+		w.zoom = zoomStep(w.zoom, false)
+	}
+	w.offsetX, w.offsetY = cellX-float64(x)/w.zoom, cellY-float64(y)/w.zoom
+}
+
+// Pan moves the board by dx, dy pixels of the window.
+func (w *Window) Pan(dx, dy int32) {
+	w.offsetX -= float64(dx) / w.zoom
+	w.offsetY -= float64(dy) / w.zoom
+}
+
+// Fit picks the largest zoom that shows the whole board and puts it in the middle of the window.
+func (w *Window) Fit() {
+	windowWidth, windowHeight := w.window.GetSize()
+	w.zoom = maxZoom
+	for w.zoom > minZoom && (float64(w.Width)*w.zoom > float64(windowWidth) || float64(w.Height)*w.zoom > float64(windowHeight)) {
+		w.zoom = zoomStep(w.zoom, false)
+	}
+	w.centre()
+}
+
+// ResetZoom goes back to the starting scale with the board in the middle of the window.
+func (w *Window) ResetZoom() {
+	w.zoom = w.scale
+	w.centre()
+}
+
+func (w *Window) centre() {
+	windowWidth, windowHeight := w.window.GetSize()
+	w.offsetX = (float64(w.Width) - float64(windowWidth)/w.zoom) / 2
+	w.offsetY = (float64(w.Height) - float64(windowHeight)/w.zoom) / 2
+}
+
+// CellAt returns the cell under x, y of the window, which may be outside the board.
+func (w *Window) CellAt(x, y int32) (int, int) {
+	return int(math.Floor(w.offsetX + float64(x)/w.zoom)), int(math.Floor(w.offsetY + float64(y)/w.zoom))
+}
+
 func (w *Window) PollEvent() sdl.Event {
 	return sdl.PollEvent()
 }
 
 func (w *Window) SetPixel(x, y int) {
-	width := int(w.Width)
-	w.pixels[4*(y*width+x)+0] = 0xFF
-	w.pixels[4*(y*width+x)+1] = 0xFF
-	w.pixels[4*(y*width+x)+2] = 0xFF
-	w.pixels[4*(y*width+x)+3] = 0xFF
+	i := y*int(w.Width) + x
+	w.cells[i].alive = true
+	w.cells[i].changed = w.turn
+	w.paint(i)
 }
 
+// FlipPixel flips a cell on the current turn.
 func (w *Window) FlipPixel(x, y int) {
+	w.FlipCell(x, y, w.turn)
+}
+
+// FlipCell flips a cell on the given turn, which is the turn of its CellFlipped event.
+func (w *Window) FlipCell(x, y, turn int) {
 	if x < 0 || y < 0 || x >= int(w.Width) || y >= int(w.Height) {
 		panic(fmt.Sprintf("CellFlipped event at (%d, %d) is outside the bounds of the window.", x, y))
 	}
 
-	width := int(w.Width)
-	w.pixels[4*(y*width+x)+0] = ^w.pixels[4*(y*width+x)+0]
-	w.pixels[4*(y*width+x)+1] = ^w.pixels[4*(y*width+x)+1]
-	w.pixels[4*(y*width+x)+2] = ^w.pixels[4*(y*width+x)+2]
-	w.pixels[4*(y*width+x)+3] = ^w.pixels[4*(y*width+x)+3]
+	i := y*int(w.Width) + x
+	w.cells[i].flip(turn)
+	w.paint(i)
+}
+
+// CellAlive reports whether a cell is alive, cells outside the window are dead.
+func (w *Window) CellAlive(x, y int) bool {
+	if x < 0 || y < 0 || x >= int(w.Width) || y >= int(w.Height) {
+		return false
+	}
+	return w.cells[y*int(w.Width)+x].alive
 }
 
 func (w *Window) CountPixels() int {
 	count := 0
-	for i := 0; i < int(w.Width)*int(w.Height)*4; i += 4 {
-		if w.pixels[i] == 0xFF {
+	for _, c := range w.cells {
+		if c.alive {
 			count++
 		}
 	}
@@ -98,7 +244,45 @@ func (w *Window) CountPixels() int {
 }
 
 func (w *Window) ClearPixels() {
-	for i := range w.pixels {
-		w.pixels[i] = 0
+	for i := range w.cells {
+		w.cells[i] = cell{}
+	}
+	w.repaint()
+}
+
+// SetTurn moves the window on to a completed turn.
+// Cells only cool down when they are flipped or coloured in the heat mode, so this does not touch them.
+func (w *Window) SetTurn(turn int) {
+	w.turn = turn
+	// plain colours do not change with the turn, the others are worked out when the next frame is drawn
+	w.recolour = w.mode != Plain
+}
+
+// Mode returns the colour mode.
+func (w *Window) Mode() ColourMode {
+	return w.mode
+}
+
+// SetMode changes the colour mode and shows it in the title of the window.
+func (w *Window) SetMode(mode ColourMode) {
+	w.mode = mode
+	w.window.SetTitle("GOL GUI - " + mode.String())
+	w.repaint()
+}
+
+// paint sets the pixel of the cell at index i from its state and the colour mode.
+func (w *Window) paint(i int) {
+	w.dirty = true
+	colour := w.cells[i].colour(w.mode, w.turn)
+	// ARGB8888 is stored as B, G, R, A on little endian machines
+	w.pixels[4*i+0] = colour.b
+	w.pixels[4*i+1] = colour.g
+	w.pixels[4*i+2] = colour.r
+	w.pixels[4*i+3] = 0xFF
+}
+
+func (w *Window) repaint() {
+	for i := range w.cells {
+		w.paint(i)
 	}
 }
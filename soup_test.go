@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSoup tests that a soup has about the right density and that the same seed always gives the same board.
+func TestSoup(t *testing.T) {
+	board := func(soup gol.Soup) []util.Cell {
+		return runFinal(t, gol.Params{Turns: 0, Threads: 1, ImageWidth: 64, ImageHeight: 64, Soup: soup})
+	}
+	first := board(gol.Soup{Density: 0.35, Seed: 42})
+	if alive := float64(len(first)) / (64 * 64); alive < 0.3 || alive > 0.4 {
+		t.Fatalf("Expected about 35%% of the cells to be alive, got %.1f%%", alive*100)
+	}
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64}
+	assertEqualBoard(t, board(gol.Soup{Density: 0.35, Seed: 42}), first, p)
+	cells := make(map[util.Cell]bool)
+	for _, cell := range first {
+		cells[cell] = true
+	}
+	same := true
+	other := board(gol.Soup{Density: 0.35, Seed: 43})
+	for _, cell := range other {
+		same = same && cells[cell]
+	}
+	if same && len(other) == len(first) {
+		t.Fatal("Expected a different seed to give a different board")
+	}
+
+	for _, bad := range []string{"", "0.5", "0,1", "1.5,1", "x,1"} {
+		if _, err := gol.ParseSoup(bad); err == nil {
+			t.Errorf("Expected an error for soup %q", bad)
+		}
+	}
+}
+
+// TestBatch tests that a batch gives the same results however many soups run at once,
+// and that the lifespans are where a single run finds its cycle.
+func TestBatch(t *testing.T) {
+	p := gol.Params{Turns: 5000, ImageWidth: 32, ImageHeight: 32, Soup: gol.Soup{Density: 0.4, Seed: 100}}
+	batch := func(threads int) [][]string {
+		p.Threads = threads
+		var out bytes.Buffer
+		if err := gol.RunBatch(p, 20, &out); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&out).ReadAll()
+		util.Check(err)
+		return rows
+	}
+	rows := batch(4)
+	if len(rows) != 21 || rows[0][0] != "seed" {
+		t.Fatalf("Expected a header and 20 rows, got %v", rows)
+	}
+	for i, row := range batch(1)[1:] {
+		if row[0] != strconv.Itoa(100+i) {
+			t.Fatalf("Expected row %v to be seed %v, got %v", i, 100+i, row[0])
+		}
+		for j := range row {
+			if row[j] != rows[i+1][j] {
+				t.Fatalf("Seed %v gave %v on one thread and %v on four", row[0], row, rows[i+1])
+			}
+		}
+	}
+
+	for _, row := range rows[1:4] {
+		seed, _ := strconv.ParseInt(row[0], 10, 64)
+		lifespan, err := strconv.Atoi(row[1])
+		if err != nil {
+			t.Fatalf("Expected seed %v to settle, got %v", seed, row)
+		}
+		single := gol.Params{
+			Turns:        lifespan + 100,
+			Threads:      4,
+			ImageWidth:   32,
+			ImageHeight:  32,
+			Soup:         gol.Soup{Density: 0.4, Seed: seed},
+			DetectCycles: 64,
+		}
+		events := make(chan gol.Event)
+		go gol.Run(single, events, nil)
+		found := false
+		for event := range events {
+			if e, ok := event.(gol.CycleDetected); ok {
+				found = true
+				if e.CompletedTurns-e.Period != lifespan {
+					t.Fatalf("Expected seed %v to settle on turn %v, a single run found %v", seed, lifespan, e.CompletedTurns-e.Period)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("Expected a single run of seed %v to find a cycle", seed)
+		}
+	}
+}
+
+// TestBatchSettings tests that a batch asked to use HashLife or a broker reports an error
+// instead of quietly running locally with RowStrips.
+func TestBatchSettings(t *testing.T) {
+	p := gol.Params{Turns: 5000, Threads: 4, ImageWidth: 32, ImageHeight: 32, Soup: gol.Soup{Density: 0.4, Seed: 100}}
+	hashLife := p
+	hashLife.Engine = gol.HashLife
+	hashLife.HashLifeStep = 6
+	broker := p
+	broker.Broker = "127.0.0.1:8030"
+	for _, test := range []gol.Params{hashLife, broker} {
+		var out bytes.Buffer
+		if err := gol.RunBatch(test, 10, &out); err == nil {
+			t.Fatalf("Expected an error from a batch with engine %v and broker %q", test.Engine, test.Broker)
+		}
+	}
+}
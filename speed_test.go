@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestSpeed tests that TurnsPerSecond slows the run down, that '+' speeds it back up,
+// and that the final board is the same either way.
+func TestSpeed(t *testing.T) {
+	tests := []struct {
+		name           string
+		turnsPerSecond float64
+		faster         int
+		min, max       time.Duration
+	}{
+		// 100 turns at 500 turns per second take at least 0.2s
+		{"limited", 500, 0, 150 * time.Millisecond, 10 * time.Second},
+		// 100 turns at 10 turns per second would take 10s, doubling 7 times goes past the limit
+		{"faster", 10, 7, 0, 5 * time.Second},
+	}
+	for _, test := range tests {
+		p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16, TurnsPerSecond: test.turnsPerSecond}
+		t.Run(test.name, func(t *testing.T) {
+			keyPresses := make(chan rune, test.faster)
+			for i := 0; i < test.faster; i++ {
+				keyPresses <- '+'
+			}
+			start := time.Now()
+			events := make(chan gol.Event)
+			go gol.Run(p, events, keyPresses)
+			turns := 0
+			var final gol.FinalTurnComplete
+			for event := range events {
+				switch e := event.(type) {
+				case gol.ErrorOccurred:
+					t.Fatal(e.Err)
+				case gol.TurnComplete:
+					turns++
+				case gol.FinalTurnComplete:
+					final = e
+				}
+			}
+			elapsed := time.Since(start)
+			if elapsed < test.min || elapsed > test.max {
+				t.Fatalf("Expected the run to take between %v and %v, took %v", test.min, test.max, elapsed)
+			}
+			// a limited speed reports every turn
+			if turns != p.Turns {
+				t.Fatalf("Expected %v TurnComplete events, got %v", p.Turns, turns)
+			}
+			assertEqualBoard(t, final.Alive, readAliveCells("check/images/16x16x100.pgm", 16, 16), p)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// readCSV reads every row of a csv file.
+func readCSV(path string) [][]string {
+	f, err := os.Open(path)
+	util.Check(err)
+	defer f.Close()
+	table, err := csv.NewReader(f).ReadAll()
+	util.Check(err)
+	return table
+}
+
+// boundingBox returns the bounding box of the alive cells as it is written to the stats file,
+// which is empty if there are none.
+func boundingBox(cells []util.Cell, size int) []string {
+	if len(cells) == 0 {
+		return []string{"", "", "", ""}
+	}
+	minX, minY, maxX, maxY := size, size, -1, -1
+	for _, cell := range cells {
+		if cell.X < minX {
+			minX = cell.X
+		}
+		if cell.Y < minY {
+			minY = cell.Y
+		}
+		if cell.X > maxX {
+			maxX = cell.X
+		}
+		if cell.Y > maxY {
+			maxY = cell.Y
+		}
+	}
+	return []string{strconv.Itoa(minX), strconv.Itoa(minY), strconv.Itoa(maxX), strconv.Itoa(maxY)}
+}
+
+// TestStats tests that the stats file starts with the same columns as check/alive,
+// that births and deaths add up, and that the bounding box of every turn fits the board.
+// The 64x64 run keeps a history, so some of its rows are held back until the end.
+func TestStats(t *testing.T) {
+	for _, size := range []int{16, 64} {
+		p := gol.Params{
+			Turns:       10000,
+			Threads:     4,
+			ImageWidth:  size,
+			ImageHeight: size,
+			Stats:       fmt.Sprintf("out/stats-%dx%d.csv", size, size),
+		}
+		if size == 64 {
+			p.History = 100
+		}
+		t.Run(p.Stats, func(t *testing.T) {
+			events := make(chan gol.Event)
+			go gol.Run(p, events, nil)
+			board := make(map[util.Cell]bool)
+			boxes := make(map[string][]string)
+			for event := range events {
+				switch e := event.(type) {
+				case gol.ErrorOccurred:
+					t.Fatal(e.Err)
+				case gol.CellFlipped:
+					board[e.Cell] = !board[e.Cell]
+				case gol.TurnComplete:
+					boxes[strconv.Itoa(e.CompletedTurns)] = boundingBox(boardCells(board), size)
+				}
+			}
+
+			stats := readCSV(p.Stats)
+			expected := readCSV(fmt.Sprintf("check/alive/%dx%d.csv", size, size))
+			if len(stats) != len(expected) {
+				t.Fatalf("Expected %v rows, got %v", len(expected), len(stats))
+			}
+
+			previous := len(readAliveCells(fmt.Sprintf("check/images/%dx%dx0.pgm", size, size), size, size))
+			for i, row := range stats {
+				if row[0] != expected[i][0] || row[1] != expected[i][1] {
+					t.Fatalf("Row %v is %v, expected it to start with %v", i, row, expected[i])
+				}
+				if i == 0 {
+					continue
+				}
+				numbers := make([]int, 4)
+				for j := range numbers {
+					numbers[j], _ = strconv.Atoi(row[j])
+				}
+				alive, births, deaths := numbers[1], numbers[2], numbers[3]
+				if alive-previous != births-deaths {
+					t.Fatalf("Turn %v went from %v to %v alive cells with %v births and %v deaths", row[0], previous, alive, births, deaths)
+				}
+				previous = alive
+				if density := strconv.FormatFloat(float64(alive)/float64(size*size), 'g', -1, 64); row[8] != density {
+					t.Fatalf("Turn %v has a density of %v, expected %v", row[0], row[8], density)
+				}
+				if box := boxes[row[0]]; fmt.Sprint(row[4:8]) != fmt.Sprint(box) {
+					t.Fatalf("Turn %v has a bounding box of %v, expected %v", row[0], row[4:8], box)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// boardCells returns the alive cells of a board built up from CellFlipped events.
+func boardCells(board map[util.Cell]bool) []util.Cell {
+	var cells []util.Cell
+	for cell, alive := range board {
+		if alive {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// boardKey returns the alive cells of a board in a form that can be compared.
+func boardKey(board map[util.Cell]bool) string {
+	var cells []string
+	for _, cell := range boardCells(board) {
+		cells = append(cells, fmt.Sprint(cell))
+	}
+	sort.Strings(cells)
+	return fmt.Sprint(cells)
+}
+
+// TestStep tests that 'n' steps forward a single turn and 'b' steps back through earlier boards while paused,
+// with TurnComplete events for the right turns, and that the run still ends on the right board.
+func TestStep(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16, History: 5}
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 10)
+	go gol.Run(p, events, keyPresses)
+
+	alive := readAliveCounts(16, 16)
+	board := make(map[util.Cell]bool)
+	seen := make(map[int]string)
+	pausedTurn := -1
+	var turns []int
+	var final []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.TurnComplete:
+			key := boardKey(board)
+			if previous, ok := seen[e.CompletedTurns]; ok && previous != key {
+				t.Fatalf("The board at turn %v is not the same as the first time", e.CompletedTurns)
+			}
+			seen[e.CompletedTurns] = key
+			if count := len(boardCells(board)); e.CompletedTurns > 0 && count != alive[e.CompletedTurns] {
+				t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, alive[e.CompletedTurns], count)
+			}
+			if pausedTurn >= 0 {
+				turns = append(turns, e.CompletedTurns)
+			} else if e.CompletedTurns == 10 {
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				pausedTurn = e.CompletedTurns
+				for _, key := range "nnnbbbbbp" {
+					keyPresses <- key
+				}
+			}
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	}
+	if pausedTurn == -1 {
+		t.Fatal("The board was never paused")
+	}
+
+	// the turns while paused, then the run carries on from two turns before the pause
+	expected := []int{1, 2, 3, 2, 1, 0, -1, -2}
+	for i, offset := range expected {
+		if i >= len(turns) || turns[i] != pausedTurn+offset {
+			t.Fatalf("Expected the turns while paused to be %v after turn %v, got %v", expected, pausedTurn, turns)
+		}
+	}
+	if len(turns) <= len(expected) || turns[len(expected)] != pausedTurn-1 {
+		t.Fatalf("Expected the run to carry on from turn %v, got %v", pausedTurn-2, turns)
+	}
+	assertEqualBoard(t, final, readAliveCells("check/images/16x16x100.pgm", 16, 16), p)
+}
+
+// TestStepUndoEdit tests that 'b' undoes an edit made while paused before it steps back any turns.
+func TestStepUndoEdit(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 16, ImageHeight: 16, History: 5}
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 10)
+	edits := make(chan util.Cell, 1)
+	go gol.RunWithEdits(p, events, keyPresses, edits)
+
+	pausedTurn := -1
+	edited, undone := false, false
+	var final []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.ErrorOccurred:
+			t.Fatal(e.Err)
+		case gol.CellFlipped:
+			if pausedTurn >= 0 && !edited {
+				// the edit has been made, so undo it and carry on
+				edited = true
+				keyPresses <- 'b'
+				keyPresses <- 'p'
+			}
+		case gol.TurnComplete:
+			if edited && !undone {
+				undone = true
+				if e.CompletedTurns != pausedTurn {
+					t.Fatalf("Expected the undo to stay on turn %v, got turn %v", pausedTurn, e.CompletedTurns)
+				}
+			}
+			if pausedTurn == -1 && e.CompletedTurns == 10 {
+				keyPresses <- 'p'
+			}
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				pausedTurn = e.CompletedTurns
+				edits <- util.Cell{X: 3, Y: 3}
+			}
+		case gol.FinalTurnComplete:
+			final = e.Alive
+		}
+	}
+	if !undone {
+		t.Fatal("The edit was never undone")
+	}
+	assertEqualBoard(t, final, readAliveCells("check/images/16x16x100.pgm", 16, 16), p)
+}
@@ -0,0 +1,73 @@
+package stubs
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// Names of the methods served over net/rpc by the broker and the workers.
+var (
+	BrokerStart    = "Broker.Start"
+	BrokerStep     = "Broker.Step"
+	BrokerWorld    = "Broker.World"
+	BrokerShutdown = "Broker.Shutdown"
+	BrokerDetach   = "Broker.Detach"
+	BrokerAttach   = "Broker.Attach"
+
+	WorkerCalculate = "Worker.Calculate"
+	WorkerShutdown  = "Worker.Shutdown"
+)
+
+// World is a bit-packed world, each row stores 64 cells per uint64.
+type World struct {
+	Width, Height int
+	Rows          [][]uint64
+}
+
+// StartRequest hands a world to the broker, replacing any world it already holds.
+// Turns is the number of turns the broker runs up to if the controller detaches.
+type StartRequest struct {
+	World    World
+	Turn     int
+	Turns    int
+	Rule     string
+	Topology int
+}
+
+// AttachRequest asks the broker for the world it is running, which has to be Width x Height.
+type AttachRequest struct {
+	Width, Height int
+}
+
+// StepResponse reports the cells flipped by a single turn in row-major order.
+type StepResponse struct {
+	Turn    int
+	Flipped []util.Cell
+	Alive   int
+}
+
+// WorldResponse holds the world at the given completed turn.
+type WorldResponse struct {
+	World World
+	Turn  int
+}
+
+// StripRequest asks a worker to calculate rows [StartY, EndY) of the next turn.
+// Above and Below are the rows just outside the strip, wrapping around the world.
+// Left and Right are the edge columns of the whole world, which some topologies need.
+type StripRequest struct {
+	StartY, EndY  int
+	Width, Height int
+	Rows          [][]uint64
+	Above, Below  []uint64
+	Left, Right   []bool
+	Rule          string
+	Topology      int
+}
+
+// StripResponse holds the calculated rows along with the flipped cells and the alive count of the strip.
+type StripResponse struct {
+	Rows    [][]uint64
+	Flipped []util.Cell
+	Alive   int
+}
+
+// Empty is used for requests and responses that carry no data.
+type Empty struct{}
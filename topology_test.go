@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTopology tests a glider crossing the edges of a 16x16 image on 1, 40 and 100 turns
+// and a 64x64 image on 100 turns for every topology using 1-8 worker threads.
+func TestTopology(t *testing.T) {
+	tests := []struct {
+		p     gol.Params
+		turns []int
+	}{
+		{gol.Params{ImageWidth: 16, ImageHeight: 16}, []int{1, 40, 100}},
+		{gol.Params{ImageWidth: 64, ImageHeight: 64}, []int{100}},
+	}
+	topologies := []gol.Topology{gol.Torus, gol.Plane, gol.KleinBottle, gol.Cylinder, gol.ProjectivePlane}
+	for _, topology := range topologies {
+		for _, test := range tests {
+			p := test.p
+			p.Topology = topology
+			for _, turns := range test.turns {
+				p.Turns = turns
+				expectedAlive := readAliveCells(
+					"check/topology/"+topology.String()+"/"+fmt.Sprintf("%vx%vx%v.pgm", p.ImageWidth, p.ImageHeight, turns),
+					p.ImageWidth,
+					p.ImageHeight,
+				)
+				for threads := 1; threads <= 8; threads++ {
+					p.Threads = threads
+					testName := fmt.Sprintf("%v-%dx%dx%d-%d", topology, p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+					t.Run(testName, func(t *testing.T) {
+						events := make(chan gol.Event)
+						go gol.Run(p, events, nil)
+						var cells []util.Cell
+						for event := range events {
+							switch e := event.(type) {
+							case gol.FinalTurnComplete:
+								cells = e.Alive
+							}
+						}
+						assertEqualBoard(t, cells, expectedAlive, p)
+					})
+				}
+			}
+		}
+	}
+}
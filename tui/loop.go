@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Style is how the board is drawn with text.
+type Style int
+
+const (
+	// HalfBlocks draws two rows of cells in every line.
+	HalfBlocks Style = iota
+	// Braille draws a 2x4 block of cells in every character, so big boards still fit.
+	Braille
+)
+
+// ParseStyle parses "half" or "braille".
+func ParseStyle(s string) (Style, error) {
+	switch s {
+	case "half":
+		return HalfBlocks, nil
+	case "braille":
+		return Braille, nil
+	}
+	return HalfBlocks, fmt.Errorf("unknown terminal style %q, expected half or braille", s)
+}
+
+// frameInterval is the shortest time between two frames, so that the terminal can keep up with fast runs.
+const frameInterval = 50 * time.Millisecond
+
+// ANSI escape sequences
+const (
+	clearScreen = "\x1b[2J"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// terminal holds the board as it is drawn.
+type terminal struct {
+	p       gol.Params
+	style   Style
+	board   [][]uint8
+	alive   int
+	turn    int
+	state   string
+	message string
+	drawn   time.Time
+	out     *bufio.Writer
+}
+
+func (t *terminal) flip(cell util.Cell) {
+	t.board[cell.Y][cell.X] = ^t.board[cell.Y][cell.X]
+	if t.board[cell.Y][cell.X] == 0xFF {
+		t.alive++
+	} else {
+		t.alive--
+	}
+}
+
+// draw redraws the whole board and the status line under it.
+func (t *terminal) draw() {
+	t.drawn = time.Now()
+	_, _ = t.out.WriteString(cursorHome)
+	var board string
+	if t.style == Braille {
+		board = util.BrailleToString(t.board, t.p.ImageWidth, t.p.ImageHeight)
+	} else {
+		board = util.HalfBlocksToString(t.board, t.p.ImageWidth, t.p.ImageHeight)
+	}
+	// a carriage return keeps the lines in place even if the terminal is left in raw mode
+	_, _ = t.out.WriteString(strings.Replace(board, "\n", "\r\n", -1))
+	_, _ = fmt.Fprintf(t.out, "Turn %-8v Alive %-8v %v%v\r\n", t.turn, t.alive, t.state, clearLine)
+	_, _ = fmt.Fprintf(t.out, "%v%v\r\n", t.message, clearLine)
+	_ = t.out.Flush()
+}
+
+// readKeys sends every p, s, q, k, n, b, + and - typed on stdin to the distributor until done is closed.
+// An interrupt is turned into q, so that the run finishes and the terminal is restored.
+// Once done is closed interrupts are handled as normal again.
+func readKeys(keyPresses chan<- rune, done <-chan bool) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		defer signal.Stop(interrupts)
+		for {
+			select {
+			case <-interrupts:
+				select {
+				case keyPresses <- 'q':
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// a read from stdin cannot be cancelled, so the reader stops at the first key after done is closed
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return
+		}
+		switch r {
+		case 'p', 's', 'q', 'k', 'n', 'b', '+', '-':
+		case '=':
+			// + without shift
+			r = '+'
+		default:
+			continue
+		}
+		select {
+		case keyPresses <- r:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Run draws the events of a run in the terminal until the events channel is closed.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, style Style) {
+	restore := enterCbreak()
+	defer restore()
+
+	t := &terminal{
+		p:     p,
+		style: style,
+		board: make([][]uint8, p.ImageHeight),
+		state: gol.Executing.String(),
+		out:   bufio.NewWriter(os.Stdout),
+	}
+	for i := range t.board {
+		t.board[i] = make([]uint8, p.ImageWidth)
+	}
+	_, _ = t.out.WriteString(clearScreen + hideCursor)
+	defer func() {
+		_, _ = t.out.WriteString(showCursor)
+		_ = t.out.Flush()
+	}()
+
+	done := make(chan bool)
+	defer close(done)
+	go readKeys(keyPresses, done)
+
+	for event := range events {
+		t.turn = event.GetCompletedTurns()
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			t.flip(e.Cell)
+		case gol.TurnComplete:
+			if time.Since(t.drawn) >= frameInterval {
+				t.draw()
+			}
+		case gol.StateChange:
+			t.state = e.NewState.String()
+			t.draw()
+		case gol.FinalTurnComplete:
+			t.message = fmt.Sprintf("Finished after %v turns", e.CompletedTurns)
+			t.draw()
+		case gol.ErrorOccurred:
+			t.message = e.String()
+			t.draw()
+		default:
+			if len(event.String()) > 0 {
+				t.message = event.String()
+			}
+		}
+	}
+	t.draw()
+}
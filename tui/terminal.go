@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// stty runs stty on the terminal connected to stdin and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// enterCbreak lets single key presses through without waiting for enter and stops them being echoed.
+// It returns a function that puts the terminal back the way it was.
+// If the terminal cannot be changed keys only arrive after enter and restoring does nothing.
+func enterCbreak() func() {
+	saved, err := stty("-g")
+	if err != nil {
+		return func() {}
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return func() {}
+	}
+	return func() {
+		_, _ = stty(saved)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTerminalRendering tests that a glider is drawn with half blocks and braille.
+func TestTerminalRendering(t *testing.T) {
+	glider := [][]uint8{
+		{0x00, 0xFF, 0x00},
+		{0x00, 0x00, 0xFF},
+		{0xFF, 0xFF, 0xFF},
+	}
+	if s := util.HalfBlocksToString(glider, 3, 3); s != " ▀▄\n▀▀▀\n" {
+		t.Fatalf("Half blocks drew\n%v", s)
+	}
+	// the first character holds columns 0 and 1, the second column 2
+	if s := util.BrailleToString(glider, 3, 3); s != "⠬⠆\n" {
+		t.Fatalf("Braille drew %q", s)
+	}
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// drainTimeout is the longest Serve waits for clients to hang up after quit is closed.
+const drainTimeout = time.Second
+
+// Serve accepts connections on the listener for the rpc server until quit is closed.
+// It then waits for the open connections to be closed by their clients, so that the reply
+// to the call that closed quit is written before the server stops.
+func Serve(server *rpc.Server, listener net.Listener, quit <-chan bool) error {
+	return ServeConns(listener, quit, func(conn net.Conn) {
+		server.ServeConn(conn)
+	})
+}
+
+// ServeConns is like Serve, but calls serve on its own goroutine for every connection,
+// which returns once the connection has closed.
+func ServeConns(listener net.Listener, quit <-chan bool, serve func(conn net.Conn)) error {
+	go func() {
+		<-quit
+		_ = listener.Close()
+	}()
+	var open sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-quit:
+				drain(&open)
+				return nil
+			default:
+				return err
+			}
+		}
+		open.Add(1)
+		go func() {
+			defer open.Done()
+			serve(conn)
+		}()
+	}
+}
+
+// drain waits for every connection to close, or for drainTimeout if a client never hangs up.
+func drain(open *sync.WaitGroup) {
+	closed := make(chan bool)
+	go func() {
+		open.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(drainTimeout):
+	}
+}
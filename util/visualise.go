@@ -106,3 +106,55 @@ func squaresToStrings(given, expected [][]uint8, width, height int) []string {
 
 	return output
 }
+
+// HalfBlocksToString draws a matrix with one character for every two rows,
+// using the upper and lower half block characters.
+func HalfBlocksToString(given [][]uint8, width, height int) string {
+	var output strings.Builder
+	for i := 0; i < height; i += 2 {
+		for j := 0; j < width; j++ {
+			top := given[i][j] == 0xFF
+			bottom := i+1 < height && given[i+1][j] == 0xFF
+			switch {
+			case top && bottom:
+				output.WriteString("█")
+			case top:
+				output.WriteString("▀")
+			case bottom:
+				output.WriteString("▄")
+			default:
+				output.WriteString(" ")
+			}
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
+
+// brailleDots maps a cell inside a 2x4 block to its dot in a braille character.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// BrailleToString draws a matrix with one braille character for every block of 2 columns and 4 rows.
+func BrailleToString(given [][]uint8, width, height int) string {
+	var output strings.Builder
+	for i := 0; i < height; i += 4 {
+		for j := 0; j < width; j += 2 {
+			char := rune(0x2800)
+			for dy := 0; dy < 4 && i+dy < height; dy++ {
+				for dx := 0; dx < 2 && j+dx < width; dx++ {
+					if given[i+dy][j+dx] == 0xFF {
+						char |= brailleDots[dy][dx]
+					}
+				}
+			}
+			output.WriteRune(char)
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
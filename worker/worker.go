@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Worker calculates strips of the next turn for a broker.
+type Worker struct {
+	quit chan bool
+	// stop closes quit once, however many times Shutdown is called
+	stop sync.Once
+}
+
+// Calculate calculates a single strip of the next turn.
+func (w *Worker) Calculate(req stubs.StripRequest, res *stubs.StripResponse) error {
+	strip, err := gol.NextStrip(req)
+	if err != nil {
+		return err
+	}
+	*res = strip
+	return nil
+}
+
+// Shutdown stops the worker once the response has been sent.
+func (w *Worker) Shutdown(req stubs.Empty, res *stubs.Empty) error {
+	w.stop.Do(func() { close(w.quit) })
+	return nil
+}
+
+// Serve serves the worker on the listener until it is shut down.
+func Serve(listener net.Listener) error {
+	w := &Worker{quit: make(chan bool)}
+	server := rpc.NewServer()
+	err := server.Register(w)
+	util.Check(err)
+	return util.Serve(server, listener, w.quit)
+}